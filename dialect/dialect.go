@@ -1,6 +1,15 @@
 package dialect
 
-import "reflect"
+import (
+	"database/sql"
+	"database/sql/driver"
+	"gamblerORM/log"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
 
 // 主要目的是使用 dialect 隔离不同数据库之间的差异，便于扩展，实现了一些特定的 SQL 语句的转换
 // 1、映射数据结构，如 Go 语言中的 int、int8、int16 等类型均对应 SQLite 中的 integer 类型
@@ -23,8 +32,44 @@ var dialectMap = map[string]Dialect{}
 type Dialect interface {
 	DataTypeOf(typ reflect.Value) string                    // 用于将 Go 语言的类型转换为数据库的数据类型
 	TableExistSQL(tableName string) (string, []interface{}) //返回某个表是否存在的 SQL 语句
+	BindVar() BindVarType                                   // 返回数据库使用的占位符风格
+	QuoteIdentifier(name string) string                     // 给表名、列名等标识符加上引号，避免和关键字冲突
+	ColumnSQL(col *Column) string                           // 返回建表语句中一列的定义，包括列名、类型和约束
+	InsertID() InsertIDType                                 // 返回插入记录后获取自增主键的方式
+	RegisterType(typ reflect.Type, sqlType string)          // 注册自定义类型在该数据库中的数据类型
+	JSONDataType() string                                   // 返回保存 JSON 的列使用的数据类型
+	UnboundedLimit() string                                 // 返回只有 OFFSET 时补上的无上限的 LIMIT，为空表示可以单独使用 OFFSET
 }
 
+// InsertIDType 表示插入记录后获取数据库生成的自增主键的方式
+type InsertIDType int
+
+const (
+	LASTID    InsertIDType = iota // sqlite3: LastInsertId 返回最后一行的主键
+	FIRSTID                       // mysql: LastInsertId 返回第一行的主键
+	RETURNING                     // postgres: 不支持 LastInsertId，通过 RETURNING 子句返回
+)
+
+// Column 描述建表语句中的一列，由 schema 根据 tag 的解析结果构造，交给 dialect 渲染为对应数据库的写法
+type Column struct {
+	Name          string // 列名，不带引号
+	Type          string // 由 DataTypeOf 推断或者 tag 中指定的类型
+	Size          int    // 字符串的长度，0 表示使用默认长度
+	PrimaryKey    bool
+	AutoIncrement bool
+	NotNull       bool
+	Unique        bool
+	Default       string // 默认值，为空表示没有默认值
+}
+
+// BindVarType 表示占位符的风格，generator 和用户的 Where 语句统一使用 ?，执行前再根据 dialect 改写
+type BindVarType int
+
+const (
+	QUESTION BindVarType = iota // sqlite3、mysql 使用 ?
+	DOLLAR                      // postgres 使用 $1, $2, ...
+)
+
 // RegisterDialect 注册 dialect 实例
 func RegisterDialect(name string, dialect Dialect) {
 	dialectMap[name] = dialect
@@ -35,3 +80,175 @@ func GetDialect(name string) (dialect Dialect, ok bool) {
 	dialect, ok = dialectMap[name]
 	return
 }
+
+// Rebind 将 query 中的 ? 占位符改写为 bindType 对应的风格，引号中的 ? 不会被改写
+func Rebind(bindType BindVarType, query string) string {
+	if bindType == QUESTION {
+		return query
+	}
+	var sb strings.Builder
+	// quote 记录当前所在的引号，为 0 表示不在引号中
+	var quote rune
+	n := 0
+	for _, c := range query {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?':
+			n++
+			sb.WriteByte('$')
+			sb.WriteString(strconv.Itoa(n))
+			continue
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+// nullTypes 记录 sql.Null* 类型包装的值的类型，这些类型的列和包装的类型使用相同的数据类型
+var nullTypes = map[reflect.Type]reflect.Type{
+	reflect.TypeOf(sql.NullString{}):  reflect.TypeOf(""),
+	reflect.TypeOf(sql.NullBool{}):    reflect.TypeOf(false),
+	reflect.TypeOf(sql.NullByte{}):    reflect.TypeOf(byte(0)),
+	reflect.TypeOf(sql.NullInt16{}):   reflect.TypeOf(int16(0)),
+	reflect.TypeOf(sql.NullInt32{}):   reflect.TypeOf(int32(0)),
+	reflect.TypeOf(sql.NullInt64{}):   reflect.TypeOf(int64(0)),
+	reflect.TypeOf(sql.NullFloat64{}): reflect.TypeOf(float64(0)),
+	reflect.TypeOf(sql.NullTime{}):    reflect.TypeOf(time.Time{}),
+}
+
+// nullableValue 返回推断列的数据类型时使用的值，指针和 sql.Null* 类型表示可以为 NULL 的列，
+// 分别转换为指向的类型和包装的类型的零值，例如 *string 和 sql.NullString 都按 string 推断
+func nullableValue(typ reflect.Value) reflect.Value {
+	t := typ.Type()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if inner, ok := nullTypes[t]; ok {
+		t = inner
+	}
+	if t == typ.Type() {
+		return typ
+	}
+	return reflect.Zero(t)
+}
+
+// typeRegistry 保存通过 RegisterType 注册的 Go 类型和数据类型的对应关系，每个 dialect 各自一份
+type typeRegistry struct {
+	mu    sync.RWMutex
+	types map[reflect.Type]string
+}
+
+// RegisterType 注册 Go 类型 typ 在该数据库中的数据类型，优先级高于其他推断规则，例如
+// d.RegisterType(reflect.TypeOf(UUID{}), "char(36)")
+func (r *typeRegistry) RegisterType(typ reflect.Type, sqlType string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.types == nil {
+		r.types = make(map[reflect.Type]string)
+	}
+	r.types[typ] = sqlType
+}
+
+// lookup 返回注册的数据类型
+func (r *typeRegistry) lookup(typ reflect.Type) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	sqlType, ok := r.types[typ]
+	return sqlType, ok
+}
+
+var (
+	valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+// dataTypeOf 是各个 dialect 推断数据类型的公共流程，kindType 根据 Kind 推断，无法推断时返回空字符串
+// 1）类型通过 RegisterType 注册过时使用注册的数据类型
+// 2）指针和 sql.Null* 按包装的类型推断
+// 3）实现了 driver.Valuer 的类型按零值调用 Value() 返回值的类型推断，例如 Value() 返回 string 的枚举类型使用字符串的数据类型
+// 4）根据 Kind 推断
+// 5）以上规则都无法推断时返回 fallback，不会 panic
+func dataTypeOf(r *typeRegistry, typ reflect.Value, kindType func(reflect.Value) string, fallback string) string {
+	if sqlType, ok := r.lookup(typ.Type()); ok {
+		return sqlType
+	}
+	typ = nullableValue(typ)
+	if sqlType, ok := r.lookup(typ.Type()); ok {
+		return sqlType
+	}
+	if v, ok := driverValue(typ); ok {
+		if sqlType := kindType(v); sqlType != "" {
+			return sqlType
+		}
+	}
+	if sqlType := kindType(typ); sqlType != "" {
+		return sqlType
+	}
+	// 只实现了 sql.Scanner 的类型无法得知存储的格式，使用 fallback 是预期的行为
+	if !reflect.PtrTo(typ.Type()).Implements(scannerType) {
+		log.Errorf("unsupported sql type %s (%s), fall back to %s", typ.Type(), typ.Kind(), fallback)
+	}
+	return fallback
+}
+
+// driverValue 对实现了 driver.Valuer 的类型，返回零值调用 Value() 的结果，Value() 出错、返回 nil 或者 panic 时 ok 为 false
+func driverValue(typ reflect.Value) (value reflect.Value, ok bool) {
+	var valuer driver.Valuer
+	switch {
+	case typ.Type().Implements(valuerType):
+		valuer, _ = reflect.Zero(typ.Type()).Interface().(driver.Valuer)
+	case reflect.PtrTo(typ.Type()).Implements(valuerType):
+		valuer, _ = reflect.New(typ.Type()).Interface().(driver.Valuer)
+	default:
+		return value, false
+	}
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	v, err := valuer.Value()
+	if err != nil || v == nil {
+		return value, false
+	}
+	return reflect.ValueOf(v), true
+}
+
+// quoteIdentifier 使用引号 q 包裹标识符，形如 table.column 的标识符会分段包裹，* 保持原样
+func quoteIdentifier(name string, q string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if part == "*" {
+			continue
+		}
+		// 标识符中本身出现的引号需要重复一次进行转义
+		parts[i] = q + strings.ReplaceAll(part, q, q+q) + q
+	}
+	return strings.Join(parts, ".")
+}
+
+// columnSQL 按 "列名 类型 约束" 的顺序拼接一列的定义，autoIncrement 是自增约束在对应数据库中的写法
+func columnSQL(d Dialect, col *Column, typ string, autoIncrement string) string {
+	parts := []string{d.QuoteIdentifier(col.Name), typ}
+	if col.PrimaryKey {
+		parts = append(parts, "PRIMARY KEY")
+	}
+	if autoIncrement != "" {
+		parts = append(parts, autoIncrement)
+	}
+	if col.NotNull {
+		parts = append(parts, "NOT NULL")
+	}
+	if col.Unique {
+		parts = append(parts, "UNIQUE")
+	}
+	if col.Default != "" {
+		parts = append(parts, "DEFAULT "+col.Default)
+	}
+	return strings.Join(parts, " ")
+}
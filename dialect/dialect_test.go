@@ -0,0 +1,105 @@
+package dialect
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestRebind(t *testing.T) {
+	cases := []struct {
+		BindType BindVarType
+		Query    string
+		Expect   string
+	}{
+		{QUESTION, "SELECT * FROM User WHERE Name = ? LIMIT ?", "SELECT * FROM User WHERE Name = ? LIMIT ?"},
+		{DOLLAR, "SELECT * FROM User WHERE Name = ? LIMIT ?", "SELECT * FROM User WHERE Name = $1 LIMIT $2"},
+		{DOLLAR, "INSERT INTO User (Name,Age) VALUES (?, ?), (?, ?)", "INSERT INTO User (Name,Age) VALUES ($1, $2), ($3, $4)"},
+		// 引号中的 ? 不是占位符
+		{DOLLAR, "SELECT * FROM User WHERE Name = '?' AND Age = ?", "SELECT * FROM User WHERE Name = '?' AND Age = $1"},
+	}
+	for _, c := range cases {
+		if sql := Rebind(c.BindType, c.Query); sql != c.Expect {
+			t.Fatalf("expect %s, but got %s", c.Expect, sql)
+		}
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	sqlite, _ := GetDialect("sqlite3")
+	postgres, _ := GetDialect("postgres")
+	cases := []struct {
+		Dialect Dialect
+		Name    string
+		Expect  string
+	}{
+		{sqlite, "Order", "`Order`"},
+		{sqlite, "User.Name", "`User`.`Name`"},
+		{sqlite, "User.*", "`User`.*"},
+		{postgres, "Group", `"Group"`},
+		{postgres, `a"b`, `"a""b"`},
+	}
+	for _, c := range cases {
+		if name := c.Dialect.QuoteIdentifier(c.Name); name != c.Expect {
+			t.Fatalf("expect %s, but got %s", c.Expect, name)
+		}
+	}
+}
+
+type uuid [16]byte
+
+func (u uuid) Value() (driver.Value, error) {
+	return fmt.Sprintf("%x", u[:]), nil
+}
+
+type money struct {
+	Cents int64
+}
+
+func (m money) Value() (driver.Value, error) {
+	return m.Cents, nil
+}
+
+type point struct {
+	X, Y float64
+}
+
+func TestDataTypeOf_CustomTypes(t *testing.T) {
+	cases := []struct {
+		Dialect Dialect
+		Value   interface{}
+		Expect  string
+	}{
+		// Valuer 按 Value() 返回值的类型推断
+		{&sqlite3{}, uuid{}, "text"},
+		{&mysql{}, money{}, "bigint"},
+		{&postgres{}, &money{}, "bigint"},
+		// 无法推断时使用 fallback，不会 panic
+		{&sqlite3{}, point{}, "text"},
+		{&mysql{}, point{}, "longtext"},
+		{&postgres{}, make(chan int), "text"},
+	}
+	for _, c := range cases {
+		if typ := c.Dialect.DataTypeOf(reflect.ValueOf(c.Value)); typ != c.Expect {
+			t.Fatalf("%T: expect %s, but got %s", c.Value, c.Expect, typ)
+		}
+	}
+}
+
+func TestDialect_RegisterType(t *testing.T) {
+	mysqlDialect, postgresDialect := &mysql{}, &postgres{}
+	mysqlDialect.RegisterType(reflect.TypeOf(uuid{}), "char(32)")
+	postgresDialect.RegisterType(reflect.TypeOf(point{}), "point")
+	if typ := mysqlDialect.DataTypeOf(reflect.ValueOf(uuid{})); typ != "char(32)" {
+		t.Fatal("failed to use registered type, got", typ)
+	}
+	// 指针和注册的类型使用相同的数据类型
+	if typ := postgresDialect.DataTypeOf(reflect.ValueOf((*point)(nil))); typ != "point" {
+		t.Fatal("failed to use registered type for pointer, got", typ)
+	}
+	// 每个 dialect 的注册互不影响
+	if typ := postgresDialect.DataTypeOf(reflect.ValueOf(uuid{})); typ != "text" {
+		t.Fatal("registry should be per dialect, got", typ)
+	}
+}
@@ -0,0 +1,101 @@
+package dialect
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+type mysql struct {
+	typeRegistry
+}
+
+// init 包在第一次加载时，会将 mysql 的 dialect 自动注册到全局
+func init() {
+	RegisterDialect("mysql", &mysql{})
+}
+
+// DataTypeOf 用于将 Go 语言的类型转换为 mysql 数据库的数据类型，无法推断的类型使用 longtext
+func (m *mysql) DataTypeOf(typ reflect.Value) string {
+	return dataTypeOf(&m.typeRegistry, typ, m.kindType, "longtext")
+}
+
+// kindType 根据 Kind 推断 mysql 的数据类型，无法推断时返回空字符串
+func (m *mysql) kindType(typ reflect.Value) string {
+	switch typ.Kind() {
+	// mysql 没有真正的布尔类型，bool 实际上就是 tinyint(1)
+	case reflect.Bool:
+		return "tinyint(1)"
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return "int"
+	// Go 的 int 是 64 位的
+	case reflect.Int, reflect.Int64:
+		return "bigint"
+	// 无符号整数使用 unsigned 类型，避免超过有符号类型上限的值溢出
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return "int unsigned"
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		return "bigint unsigned"
+	case reflect.Float32, reflect.Float64:
+		return "double"
+	// text 类型不能直接作为主键或者建立索引，所以字符串默认使用 varchar(255)
+	case reflect.String:
+		return "varchar(255)"
+	case reflect.Array, reflect.Slice:
+		return "blob"
+	case reflect.Struct:
+		if _, ok := typ.Interface().(time.Time); ok {
+			return "datetime"
+		}
+	}
+	return ""
+}
+
+// TableExistSQL 返回在 mysql 中判断表 tableName 是否存在的 SQL 语句，只在当前连接的库中查找
+func (m *mysql) TableExistSQL(tableName string) (string, []interface{}) {
+	args := []interface{}{tableName}
+	return "SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?", args
+}
+
+// BindVar mysql 使用 ? 作为占位符
+func (m *mysql) BindVar() BindVarType {
+	return QUESTION
+}
+
+// QuoteIdentifier mysql 使用反引号包裹标识符
+func (m *mysql) QuoteIdentifier(name string) string {
+	return quoteIdentifier(name, "`")
+}
+
+// ColumnSQL 返回 mysql 建表语句中一列的定义，超出 varchar 长度上限的字符串使用 longtext
+func (m *mysql) ColumnSQL(col *Column) string {
+	typ := col.Type
+	if col.Size > 0 && typ == "varchar(255)" {
+		if col.Size > 65535 {
+			typ = "longtext"
+		} else {
+			typ = fmt.Sprintf("varchar(%d)", col.Size)
+		}
+	}
+	if col.AutoIncrement {
+		return columnSQL(m, col, typ, "AUTO_INCREMENT")
+	}
+	return columnSQL(m, col, typ, "")
+}
+
+// InsertID mysql 多行插入时 LastInsertId 返回第一行的主键
+func (m *mysql) InsertID() InsertIDType {
+	return FIRSTID
+}
+
+// JSONDataType mysql 5.7 之后支持 json 类型，写入时会校验格式
+func (m *mysql) JSONDataType() string {
+	return "json"
+}
+
+// UnboundedLimit mysql 的 OFFSET 必须跟在 LIMIT 之后，官方文档建议使用 bigint unsigned 的最大值表示没有上限
+func (m *mysql) UnboundedLimit() string {
+	return "18446744073709551615"
+}
+
+var _ Dialect = (*mysql)(nil)
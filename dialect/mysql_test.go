@@ -0,0 +1,69 @@
+package dialect
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMysql_DataTypeOf(t *testing.T) {
+	dial := &mysql{}
+	cases := []struct {
+		Value interface{}
+		Type  string
+	}{
+		{true, "tinyint(1)"},
+		{"Tom", "varchar(255)"},
+		{int32(123), "int"},
+		{123, "bigint"},
+		{int64(123), "bigint"},
+		{uint32(123), "int unsigned"},
+		{uint(123), "bigint unsigned"},
+		{uint64(123), "bigint unsigned"},
+		{1.2, "double"},
+		{[]byte("Tom"), "blob"},
+		{time.Now(), "datetime"},
+		{(*string)(nil), "varchar(255)"},
+		{(*time.Time)(nil), "datetime"},
+		{sql.NullBool{}, "tinyint(1)"},
+		{sql.NullFloat64{}, "double"},
+	}
+
+	for _, c := range cases {
+		if typ := dial.DataTypeOf(reflect.ValueOf(c.Value)); typ != c.Type {
+			t.Fatalf("expect %s, but got %s", c.Type, typ)
+		}
+	}
+}
+
+func TestMysql_TableExistSQL(t *testing.T) {
+	dial, ok := GetDialect("mysql")
+	if !ok {
+		t.Fatal("mysql dialect is not registered")
+	}
+	sql, args := dial.TableExistSQL("User")
+	if sql != "SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?" {
+		t.Fatal("failed to build table exist sql, got", sql)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"User"}) {
+		t.Fatal("failed to build table exist args, got", args)
+	}
+}
+
+func TestMysql_ColumnSQL(t *testing.T) {
+	dial := &mysql{}
+	cases := []struct {
+		Column *Column
+		SQL    string
+	}{
+		{&Column{Name: "ID", Type: "bigint", PrimaryKey: true, AutoIncrement: true}, "`ID` bigint PRIMARY KEY AUTO_INCREMENT"},
+		{&Column{Name: "Code", Type: "varchar(255)", Size: 64, NotNull: true, Unique: true}, "`Code` varchar(64) NOT NULL UNIQUE"},
+		{&Column{Name: "Content", Type: "varchar(255)", Size: 1 << 20}, "`Content` longtext"},
+	}
+	for _, c := range cases {
+		if sql := dial.ColumnSQL(c.Column); sql != c.SQL {
+			t.Fatalf("expect %s, but got %s", c.SQL, sql)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package dialect
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+type postgres struct {
+	typeRegistry
+}
+
+// init 包在第一次加载时，会将 postgres 的 dialect 自动注册到全局
+func init() {
+	RegisterDialect("postgres", &postgres{})
+}
+
+// DataTypeOf 用于将 Go 语言的类型转换为 postgres 数据库的数据类型，无法推断的类型使用 text
+func (p *postgres) DataTypeOf(typ reflect.Value) string {
+	return dataTypeOf(&p.typeRegistry, typ, p.kindType, "text")
+}
+
+// kindType 根据 Kind 推断 postgres 的数据类型，无法推断时返回空字符串
+func (p *postgres) kindType(typ reflect.Value) string {
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	// postgres 没有无符号整数，无符号类型只能放到更宽的有符号类型中
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		return "smallint"
+	case reflect.Int32, reflect.Uint16:
+		return "integer"
+	// Go 的 int 是 64 位的；uint64 超过 bigint 上限的值仍然会溢出，需要时可以通过 RegisterType 改为 numeric(20)
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return "bigint"
+	case reflect.Float32:
+		return "real"
+	case reflect.Float64:
+		return "double precision"
+	case reflect.String:
+		return "text"
+	case reflect.Array, reflect.Slice:
+		return "bytea"
+	case reflect.Map:
+		return "jsonb"
+	case reflect.Struct:
+		// 带时区的时间戳，避免服务器时区不同导致的时间偏移
+		if _, ok := typ.Interface().(time.Time); ok {
+			return "timestamptz"
+		}
+	}
+	return ""
+}
+
+// TableExistSQL 返回在 postgres 中判断表 tableName 是否存在的 SQL 语句，只在当前 schema 中查找
+func (p *postgres) TableExistSQL(tableName string) (string, []interface{}) {
+	args := []interface{}{tableName}
+	return "SELECT tablename FROM pg_tables WHERE schemaname = current_schema() AND tablename = ?", args
+}
+
+// BindVar postgres 使用 $1, $2, ... 作为占位符
+func (p *postgres) BindVar() BindVarType {
+	return DOLLAR
+}
+
+// QuoteIdentifier postgres 使用双引号包裹标识符
+func (p *postgres) QuoteIdentifier(name string) string {
+	return quoteIdentifier(name, `"`)
+}
+
+// ColumnSQL 返回 postgres 建表语句中一列的定义，postgres 通过 serial 系列类型实现自增
+func (p *postgres) ColumnSQL(col *Column) string {
+	typ := col.Type
+	if col.Size > 0 && typ == "text" {
+		typ = fmt.Sprintf("varchar(%d)", col.Size)
+	}
+	if col.AutoIncrement {
+		switch typ {
+		case "smallint":
+			typ = "smallserial"
+		case "integer":
+			typ = "serial"
+		case "bigint":
+			typ = "bigserial"
+		}
+	}
+	return columnSQL(p, col, typ, "")
+}
+
+// InsertID postgres 通过 RETURNING 子句返回生成的主键
+func (p *postgres) InsertID() InsertIDType {
+	return RETURNING
+}
+
+// JSONDataType postgres 使用二进制存储的 jsonb，支持索引
+func (p *postgres) JSONDataType() string {
+	return "jsonb"
+}
+
+// UnboundedLimit postgres 可以单独使用 OFFSET
+func (p *postgres) UnboundedLimit() string {
+	return ""
+}
+
+var _ Dialect = (*postgres)(nil)
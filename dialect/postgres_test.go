@@ -0,0 +1,71 @@
+package dialect
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPostgres_DataTypeOf(t *testing.T) {
+	dial := &postgres{}
+	cases := []struct {
+		Value interface{}
+		Type  string
+	}{
+		{true, "boolean"},
+		{"Tom", "text"},
+		{int32(123), "integer"},
+		{123, "bigint"},
+		{int64(123), "bigint"},
+		{uint32(123), "bigint"},
+		{uint(123), "bigint"},
+		{1.2, "double precision"},
+		{[]byte("Tom"), "bytea"},
+		{map[string]interface{}{}, "jsonb"},
+		{time.Now(), "timestamptz"},
+		{(*int64)(nil), "bigint"},
+		{(*time.Time)(nil), "timestamptz"},
+		{sql.NullInt32{}, "integer"},
+		{sql.NullTime{}, "timestamptz"},
+	}
+
+	for _, c := range cases {
+		if typ := dial.DataTypeOf(reflect.ValueOf(c.Value)); typ != c.Type {
+			t.Fatalf("expect %s, but got %s", c.Type, typ)
+		}
+	}
+}
+
+func TestPostgres_TableExistSQL(t *testing.T) {
+	dial, ok := GetDialect("postgres")
+	if !ok {
+		t.Fatal("postgres dialect is not registered")
+	}
+	sql, args := dial.TableExistSQL("User")
+	sql = Rebind(dial.BindVar(), sql)
+	if sql != "SELECT tablename FROM pg_tables WHERE schemaname = current_schema() AND tablename = $1" {
+		t.Fatal("failed to build table exist sql, got", sql)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"User"}) {
+		t.Fatal("failed to build table exist args, got", args)
+	}
+}
+
+func TestPostgres_ColumnSQL(t *testing.T) {
+	dial := &postgres{}
+	cases := []struct {
+		Column *Column
+		SQL    string
+	}{
+		{&Column{Name: "ID", Type: "bigint", PrimaryKey: true, AutoIncrement: true}, `"ID" bigserial PRIMARY KEY`},
+		{&Column{Name: "Seq", Type: "integer", AutoIncrement: true}, `"Seq" serial`},
+		{&Column{Name: "Code", Type: "text", Size: 64, NotNull: true, Unique: true}, `"Code" varchar(64) NOT NULL UNIQUE`},
+		{&Column{Name: "Active", Type: "boolean", Default: "true"}, `"Active" boolean DEFAULT true`},
+	}
+	for _, c := range cases {
+		if sql := dial.ColumnSQL(c.Column); sql != c.SQL {
+			t.Fatalf("expect %s, but got %s", c.SQL, sql)
+		}
+	}
+}
@@ -1,21 +1,26 @@
 package dialect
 
 import (
-	"fmt"
 	"reflect"
 	"time"
 )
 
-type sqlite3 struct{}
+type sqlite3 struct {
+	typeRegistry
+}
 
 // init 包在第一次加载时，会将 sqlite3 的 dialect 自动注册到全局
 func init() {
 	RegisterDialect("sqlite3", &sqlite3{})
 }
 
-// DataTypeOf 用于将 Go 语言的类型转换为 sqlite3 数据库的数据类型
+// DataTypeOf 用于将 Go 语言的类型转换为 sqlite3 数据库的数据类型，无法推断的类型使用 text
 func (s *sqlite3) DataTypeOf(typ reflect.Value) string {
-	//TODO implement me
+	return dataTypeOf(&s.typeRegistry, typ, s.kindType, "text")
+}
+
+// kindType 根据 Kind 推断 sqlite3 的数据类型，无法推断时返回空字符串
+func (s *sqlite3) kindType(typ reflect.Value) string {
 	switch typ.Kind() {
 	case reflect.Bool:
 		return "bool"
@@ -36,7 +41,7 @@ func (s *sqlite3) DataTypeOf(typ reflect.Value) string {
 			return "datetime"
 		}
 	}
-	panic(fmt.Sprintf("sqlite3.go : invalid sql type %s (%s)", typ.Type().Name(), typ.Kind()))
+	return ""
 }
 
 // TableExistSQL  返回在 SQLite 中判断表 tableName 是否存在的 SQL 语句
@@ -46,6 +51,39 @@ func (s *sqlite3) TableExistSQL(tableName string) (string, []interface{}) {
 	return "SELECT name FROM sqlite_master WHERE type='table' and name = ?", args
 }
 
+// BindVar sqlite3 使用 ? 作为占位符
+func (s *sqlite3) BindVar() BindVarType {
+	return QUESTION
+}
+
+// QuoteIdentifier sqlite3 使用反引号包裹标识符
+func (s *sqlite3) QuoteIdentifier(name string) string {
+	return quoteIdentifier(name, "`")
+}
+
+// ColumnSQL 返回 sqlite3 建表语句中一列的定义，sqlite3 只有 integer PRIMARY KEY 才能自增，且不限制字符串长度
+func (s *sqlite3) ColumnSQL(col *Column) string {
+	if col.AutoIncrement && col.PrimaryKey {
+		return columnSQL(s, col, "integer", "AUTOINCREMENT")
+	}
+	return columnSQL(s, col, col.Type, "")
+}
+
+// InsertID sqlite3 多行插入时 LastInsertId 返回最后一行的主键
+func (s *sqlite3) InsertID() InsertIDType {
+	return LASTID
+}
+
+// JSONDataType sqlite3 没有 JSON 类型，使用 text 保存
+func (s *sqlite3) JSONDataType() string {
+	return "text"
+}
+
+// UnboundedLimit sqlite3 的 OFFSET 必须跟在 LIMIT 之后，LIMIT 为负数时表示没有上限
+func (s *sqlite3) UnboundedLimit() string {
+	return "-1"
+}
+
 // 通过如下检测确保某个类型实现了某个接口的所有方法
 // 注释：将空值 nil 转换为 *sqlite3 类型，再转换为 Dialect 接口，如果转换失败，说明 sqlite3 并没有实现 Dialect 接口的所有方法
 var _ Dialect = (*sqlite3)(nil)
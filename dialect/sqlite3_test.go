@@ -1,6 +1,7 @@
 package dialect
 
 import (
+	"database/sql"
 	"reflect"
 	"testing"
 )
@@ -15,6 +16,11 @@ func TestSqlite3_DataTypeOf(t *testing.T) {
 		{123, "integer"},
 		{1.2, "real"},
 		{[]int{1, 2, 3}, "blob"},
+		{(*string)(nil), "text"},
+		{new(int), "integer"},
+		{sql.NullString{}, "text"},
+		{sql.NullInt64{}, "bigint"},
+		{sql.NullTime{}, "datetime"},
 	}
 
 	for _, c := range cases {
@@ -23,3 +29,20 @@ func TestSqlite3_DataTypeOf(t *testing.T) {
 		}
 	}
 }
+
+func TestSqlite3_ColumnSQL(t *testing.T) {
+	dial := &sqlite3{}
+	cases := []struct {
+		Column *Column
+		SQL    string
+	}{
+		{&Column{Name: "ID", Type: "bigint", PrimaryKey: true, AutoIncrement: true}, "`ID` integer PRIMARY KEY AUTOINCREMENT"},
+		{&Column{Name: "Code", Type: "text", Size: 64, NotNull: true, Unique: true}, "`Code` text NOT NULL UNIQUE"},
+		{&Column{Name: "Price", Type: "integer", Default: "0"}, "`Price` integer DEFAULT 0"},
+	}
+	for _, c := range cases {
+		if sql := dial.ColumnSQL(c.Column); sql != c.SQL {
+			t.Fatalf("expect %s, but got %s", c.SQL, sql)
+		}
+	}
+}
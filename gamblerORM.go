@@ -1,17 +1,22 @@
 package gamblerORM
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"gamblerORM/dialect"
 	"gamblerORM/log"
+	"gamblerORM/schema"
 	"gamblerORM/session"
 	"strings"
+	"time"
 )
 
 type Engine struct {
-	db      *sql.DB         // 数据库句柄
-	dialect dialect.Dialect // 添加 dialect 实现对不同数据库的支持
+	db      *sql.DB               // 数据库句柄
+	dialect dialect.Dialect       // 添加 dialect 实现对不同数据库的支持
+	naming  schema.NamingStrategy // 表名和列名的命名规则
+	nowFunc func() time.Time      // 自动时间戳和软删除使用的时钟，为 nil 时使用 time.Now
 }
 
 type TxFunc func(*session.Session) (interface{}, error)
@@ -38,6 +43,7 @@ func NewEngine(driver, source string) (e *Engine, err error) {
 	e = &Engine{
 		db:      db,
 		dialect: dialect,
+		naming:  schema.DefaultNaming{},
 	}
 	log.Info("Connection database success")
 	return
@@ -51,15 +57,33 @@ func (engine *Engine) Close() {
 	log.Info("Close database success")
 }
 
+// SetNamingStrategy 设置表名和列名的命名规则，之后创建的会话都会使用该规则
+func (engine *Engine) SetNamingStrategy(naming schema.NamingStrategy) {
+	engine.naming = naming
+}
+
+// SetNowFunc 设置自动时间戳和软删除使用的时钟，之后创建的会话都会使用该时钟，便于测试中固定时间
+func (engine *Engine) SetNowFunc(now func() time.Time) {
+	engine.nowFunc = now
+}
+
 // NewSession 创建新会话,会话中返回一个数据库的引擎
 func (engine *Engine) NewSession() *session.Session {
-	return session.New(engine.db, engine.dialect)
+	s := session.New(engine.db, engine.dialect)
+	s.SetNamingStrategy(engine.naming)
+	s.SetNowFunc(engine.nowFunc)
+	return s
 }
 
 // Transaction 提供对封装的事务方法的调用
 func (engine *Engine) Transaction(f TxFunc) (result interface{}, err error) {
-	s := engine.NewSession()
-	if err := s.Begin(); err != nil {
+	return engine.TransactionContext(context.Background(), nil, f)
+}
+
+// TransactionContext 在 ctx 中执行事务，opts 可以指定隔离级别和是否只读，ctx 被取消时事务会回滚
+func (engine *Engine) TransactionContext(ctx context.Context, opts *sql.TxOptions, f TxFunc) (result interface{}, err error) {
+	s := engine.NewSession().WithContext(ctx)
+	if err := s.BeginTx(opts); err != nil {
 		return nil, err
 	}
 	// 调用 defer 结束事务
@@ -97,9 +121,17 @@ func getDifference(a []string, b []string) (diff []string) {
 // Migrate 实现数据库表的合并
 func (engine *Engine) Migrate(value interface{}) error {
 	// 使用事务来实现表的合并
+	// tag 无效时不合并，避免按不完整的表结构修改数据库
+	if _, err := schema.ParseWithNaming(value, engine.dialect, engine.naming); err != nil {
+		return err
+	}
 	_, err := engine.Transaction(func(s *session.Session) (result interface{}, err error) {
+		// many2many 关联的中间表不需要合并，不存在时直接创建
+		if err = s.Model(value).CreateJoinTables(); err != nil {
+			return
+		}
 		// 如果要合并的这张表没有同名的原始表
-		if !s.Model(value).JudgeTableExist() {
+		if !s.JudgeTableExist() {
 			log.Infof("table %s doesn't exist", s.RefTable().Name)
 			return nil, err
 		}
@@ -107,8 +139,17 @@ func (engine *Engine) Migrate(value interface{}) error {
 		table := s.RefTable()
 		log.Infof("Migrate -> new table columns = %v\n", table.FieldNames)
 		// 拿到旧表的所有列，这个是从数据库中查询到的，因为新表不在数据库中，新表目前只是一个对象
-		rows, _ := s.Raw(fmt.Sprintf("SELECT * FROM %s LIMIT 1", table.Name)).QueryRows()
-		columns, _ := rows.Columns()
+		// 标识符统一通过 dialect 加上引号
+		quote := engine.dialect.QuoteIdentifier
+		rows, err := s.Raw(fmt.Sprintf("SELECT * FROM %s LIMIT 1", quote(table.Name))).QueryRows()
+		if err != nil {
+			return
+		}
+		columns, err := rows.Columns()
+		_ = rows.Close()
+		if err != nil {
+			return
+		}
 		log.Infof("Migrate -> old table columns = %v\n", columns)
 		// 得到差集， 新 - 旧 就是新增的，旧 - 新 就是要删除的
 		addCols := getDifference(table.FieldNames, columns)
@@ -116,8 +157,8 @@ func (engine *Engine) Migrate(value interface{}) error {
 		log.Infof("Add cols %v, Delete cols %v", addCols, delCols)
 
 		for _, col := range addCols {
-			f := table.GetField(col)
-			sqlStr := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", table.Name, f.Name, f.Type)
+			f := table.FieldByColumn(col)
+			sqlStr := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", quote(table.Name), quote(f.Column), f.Type)
 			if _, err = s.Raw(sqlStr).Exec(); err != nil {
 				return
 			}
@@ -127,10 +168,14 @@ func (engine *Engine) Migrate(value interface{}) error {
 		}
 		// 构建新表
 		temp := "temp_" + table.Name
-		fieldStr := strings.Join(table.FieldNames, ", ")
-		s.Raw(fmt.Sprintf("CREATE TABLE %s AS SELECT %s FROM %s;", temp, fieldStr, table.Name))
-		s.Raw(fmt.Sprintf("DROP TABLE %s;", table.Name))
-		s.Raw(fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", temp, table.Name))
+		var quotedNames []string
+		for _, name := range table.FieldNames {
+			quotedNames = append(quotedNames, quote(name))
+		}
+		fieldStr := strings.Join(quotedNames, ", ")
+		s.Raw(fmt.Sprintf("CREATE TABLE %s AS SELECT %s FROM %s;", quote(temp), fieldStr, quote(table.Name)))
+		s.Raw(fmt.Sprintf("DROP TABLE %s;", quote(table.Name)))
+		s.Raw(fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", quote(temp), quote(table.Name)))
 		_, err = s.Exec()
 		return
 	})
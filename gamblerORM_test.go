@@ -1,10 +1,13 @@
 package gamblerORM
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"gamblerORM/session"
 	"reflect"
 	"testing"
+	"time"
 )
 import _ "github.com/mattn/go-sqlite3"
 
@@ -92,3 +95,76 @@ func TestEngine_Migrate(t *testing.T) {
 		t.Fatal("Failed to migrate table User, got columns", columns)
 	}
 }
+
+func TestEngine_TransactionContext(t *testing.T) {
+	engine := OpenDB(t)
+	defer engine.Close()
+	s := engine.NewSession()
+	_ = s.Model(&User{}).DropTable()
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := engine.TransactionContext(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable}, func(s *session.Session) (result interface{}, err error) {
+		if err = s.Model(&User{}).CreateTable(); err != nil {
+			t.Fatal("failed to create table in transaction", err)
+		}
+		if _, err = s.Insert(&User{"Liup", 24}); err != nil {
+			t.Fatal("failed to insert before cancel", err)
+		}
+		// 事务执行过程中上下文被取消，之后的语句和提交都会失败
+		cancel()
+		if _, err = s.Insert(&User{"Tom", 18}); err == nil {
+			t.Fatal("expect insert to fail after cancel")
+		}
+		return
+	})
+	if !errors.Is(err, context.Canceled) || s.JudgeTableExist() {
+		t.Fatal("expect cancelled context to roll back transaction, got", err)
+	}
+}
+
+func TestEngine_TransactionContextRollback(t *testing.T) {
+	engine := OpenDB(t)
+	defer engine.Close()
+	s := engine.NewSession()
+	_ = s.Model(&User{}).DropTable()
+	errCustom := errors.New("ERROR")
+	_, err := engine.TransactionContext(context.Background(), nil, func(s *session.Session) (result interface{}, err error) {
+		if err = s.Model(&User{}).CreateTable(); err != nil {
+			t.Fatal("failed to create table in transaction", err)
+		}
+		if _, err = s.Insert(&User{"Liup", 24}); err != nil {
+			t.Fatal("failed to insert in transaction", err)
+		}
+		// 回调返回自定义 error，事务回滚且原样返回该 error
+		return nil, errCustom
+	})
+	if !errors.Is(err, errCustom) || s.JudgeTableExist() {
+		t.Fatal("expect custom error to roll back transaction, got", err)
+	}
+}
+
+type Event struct {
+	Name      string `gamblerORM:"PRIMARY KEY"`
+	CreatedAt time.Time
+	DeletedAt *time.Time
+}
+
+func TestEngine_SetNowFunc(t *testing.T) {
+	engine := OpenDB(t)
+	defer engine.Close()
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	engine.SetNowFunc(func() time.Time { return now })
+	s := engine.NewSession().Model(&Event{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	event := &Event{Name: "launch"}
+	if _, err := s.Insert(event); err != nil || !event.CreatedAt.Equal(now) {
+		t.Fatal("failed to use engine clock on insert", event, err)
+	}
+	if _, err := s.DeleteObj(event); err != nil {
+		t.Fatal("failed to soft delete", err)
+	}
+	var events []Event
+	if err := s.Unscoped().Find(&events); err != nil || len(events) != 1 || !events[0].DeletedAt.Equal(now) {
+		t.Fatal("failed to use engine clock on soft delete", events, err)
+	}
+}
@@ -63,3 +63,138 @@ func testDelete(t *testing.T) {
 		t.Fatal("failed to build SQLVars")
 	}
 }
+
+func TestClause_BuildReturning(t *testing.T) {
+	var clause Clause
+	clause.Set(INSERT, "Order", []string{"Amount"})
+	clause.Set(VALUES, []interface{}{10}, []interface{}{20})
+	clause.Set(RETURNING, "ID")
+	sql, vars := clause.Build(INSERT, VALUES, RETURNING)
+	if sql != "INSERT INTO Order (Amount) VALUES (?), (?) RETURNING ID" {
+		t.Fatal("failed to build SQL, got", sql)
+	}
+	if !reflect.DeepEqual(vars, []interface{}{10, 20}) {
+		t.Fatal("failed to build SQLVars")
+	}
+}
+
+func TestClause_AndOrWhere(t *testing.T) {
+	var clause Clause
+	clause.Set(SELECT, "User", []string{"*"})
+	clause.AndWhere("Name = ?", "Tom")
+	clause.AndWhere("Age > ? OR Age < ?", 18, 60)
+	clause.OrWhere("Name IS NULL")
+	sql, vars := clause.Build(SELECT, WHERE)
+	if sql != "SELECT * FROM User WHERE (Name = ?) AND (Age > ? OR Age < ?) OR (Name IS NULL)" {
+		t.Fatal("failed to build SQL, got", sql)
+	}
+	if !reflect.DeepEqual(vars, []interface{}{"Tom", 18, 60}) {
+		t.Fatal("failed to build SQLVars")
+	}
+}
+
+func TestClause_BuildUpdateOrder(t *testing.T) {
+	var clause Clause
+	clause.Set(UPDATE, "User", map[string]interface{}{"Name": "Tom", "Age": 30, "Email": "tom@example.com"})
+	sql, vars := clause.Build(UPDATE)
+	if sql != "UPDATE User SET Age = ?, Email = ?, Name = ?" {
+		t.Fatal("failed to build SQL in deterministic order, got", sql)
+	}
+	if !reflect.DeepEqual(vars, []interface{}{30, "tom@example.com", "Tom"}) {
+		t.Fatal("failed to build SQLVars")
+	}
+}
+
+func TestClause_Clone(t *testing.T) {
+	var clause Clause
+	clause.Set(SELECT, "User", []string{"*"})
+	clause.AndWhere("Name = ?", "Tom")
+	clone := clause.Clone()
+	clone.AndWhere("Age > ?", 18)
+	sql, _ := clause.Build(SELECT, WHERE)
+	if sql != "SELECT * FROM User WHERE Name = ?" {
+		t.Fatal("clone should not change the original clause, got", sql)
+	}
+	sql, _ = clone.Build(SELECT, WHERE)
+	if sql != "SELECT * FROM User WHERE (Name = ?) AND (Age > ?)" {
+		t.Fatal("failed to build cloned clause, got", sql)
+	}
+}
+
+func TestClause_BuildOffset(t *testing.T) {
+	var clause Clause
+	clause.Set(SELECT, "User", []string{"*"})
+	clause.Set(LIMIT, 10)
+	clause.Set(OFFSET, 20)
+	sql, vars := clause.Build(SELECT, WHERE, ORDERBY, LIMIT, OFFSET)
+	if sql != "SELECT * FROM User LIMIT ? OFFSET ?" {
+		t.Fatal("failed to build SQL, got", sql)
+	}
+	if !reflect.DeepEqual(vars, []interface{}{10, 20}) {
+		t.Fatal("failed to build SQLVars")
+	}
+}
+
+func TestClause_BuildGroupByHaving(t *testing.T) {
+	var clause Clause
+	clause.Set(SELECT, "User", []string{"Age", "count(*)"})
+	clause.Set(WHERE, "Name <> ?", "Tom")
+	clause.Set(GROUPBY, "Age")
+	clause.Set(HAVING, "count(*) > ?", 1)
+	sql, vars := clause.Build(SELECT, WHERE, GROUPBY, HAVING, ORDERBY)
+	if sql != "SELECT Age,count(*) FROM User WHERE Name <> ? GROUP BY Age HAVING count(*) > ?" {
+		t.Fatal("failed to build SQL, got", sql)
+	}
+	if !reflect.DeepEqual(vars, []interface{}{"Tom", 1}) {
+		t.Fatal("failed to build SQLVars")
+	}
+}
+
+func TestClause_BuildJoin(t *testing.T) {
+	var clause Clause
+	clause.Set(SELECT, "User", []string{"User.Name", "User.Age"})
+	clause.AddJoin("LEFT JOIN Order ON Order.UserName = User.Name AND Order.Amount > ?", 10)
+	clause.AddJoin("INNER JOIN Profile ON Profile.Name = User.Name")
+	clause.Set(WHERE, "User.Age > ?", 18)
+	sql, vars := clause.Build(SELECT, JOIN, WHERE)
+	if sql != "SELECT User.Name,User.Age FROM User LEFT JOIN Order ON Order.UserName = User.Name AND Order.Amount > ? "+
+		"INNER JOIN Profile ON Profile.Name = User.Name WHERE User.Age > ?" {
+		t.Fatal("failed to build SQL, got", sql)
+	}
+	if !reflect.DeepEqual(vars, []interface{}{10, 18}) {
+		t.Fatal("failed to build SQLVars")
+	}
+	if !clause.Has(JOIN) || clause.Clone().joins == nil {
+		t.Fatal("failed to keep joins")
+	}
+}
+
+func TestClause_WrapWhere(t *testing.T) {
+	var clause Clause
+	clause.Set(SELECT, "User", []string{"*"})
+	clause.AndWhere("Name = ?", "Tom")
+	clause.OrWhere("Age > ?", 18)
+	clause.WrapWhere("DeletedAt IS NULL")
+	sql, vars := clause.Build(SELECT, WHERE)
+	if sql != "SELECT * FROM User WHERE ((Name = ?) OR (Age > ?)) AND (DeletedAt IS NULL)" {
+		t.Fatal("failed to wrap conditions, got", sql)
+	}
+	if !reflect.DeepEqual(vars, []interface{}{"Tom", 18}) {
+		t.Fatal("failed to build SQLVars")
+	}
+}
+
+func TestClause_AndWhereAfterOr(t *testing.T) {
+	var clause Clause
+	clause.Set(SELECT, "User", []string{"*"})
+	clause.AndWhere("Name = ?", "Tom")
+	clause.OrWhere("Name = ?", "Sam")
+	clause.AndWhere("Age > ?", 18)
+	sql, vars := clause.Build(SELECT, WHERE)
+	if sql != "SELECT * FROM User WHERE ((Name = ?) OR (Name = ?)) AND (Age > ?)" {
+		t.Fatal("AndWhere should not be bypassed by previous OrWhere, got", sql)
+	}
+	if !reflect.DeepEqual(vars, []interface{}{"Tom", "Sam", 18}) {
+		t.Fatal("failed to build SQLVars")
+	}
+}
@@ -4,8 +4,17 @@ import "strings"
 
 // Clause 定义 clause 结构
 type Clause struct {
-	sql     map[Type]string
-	sqlVars map[Type][]interface{}
+	sql        map[Type]string
+	sqlVars    map[Type][]interface{}
+	conditions []condition // 通过 AndWhere、OrWhere 追加的 WHERE 条件
+	joins      []condition // 通过 AddJoin 追加的 JOIN 子句
+}
+
+// condition 是 WHERE 子句中的一个条件，or 表示和前一个条件之间用 OR 连接
+type condition struct {
+	desc string
+	vars []interface{}
+	or   bool
 }
 
 // Type 便于设置对应关键词的 generator
@@ -21,6 +30,11 @@ const (
 	UPDATE
 	DELETE
 	COUNT
+	RETURNING
+	OFFSET
+	GROUPBY
+	HAVING
+	JOIN
 )
 
 // Set 方法根据 Type 调用对应的 generator，生成该子句对应的 SQL 语句
@@ -34,6 +48,102 @@ func (c *Clause) Set(name Type, vars ...interface{}) {
 	c.sqlVars[name] = vars
 }
 
+// AndWhere 追加一个 WHERE 条件，和之前的条件之间用 AND 连接
+// 之前的条件中有 OR 时先将它们作为一个整体，Where(a).OrWhere(b).AndWhere(c) 的结果为 ((a) OR (b)) AND (c)，而不是 a OR (b AND c)
+func (c *Clause) AndWhere(desc string, vars ...interface{}) {
+	for _, cond := range c.conditions {
+		if cond.or {
+			c.groupConditions()
+			break
+		}
+	}
+	c.addCondition(condition{desc: desc, vars: vars})
+}
+
+// OrWhere 追加一个 WHERE 条件，和之前的条件之间用 OR 连接
+func (c *Clause) OrWhere(desc string, vars ...interface{}) {
+	c.addCondition(condition{desc: desc, vars: vars, or: true})
+}
+
+// WrapWhere 将已有的所有条件作为一个整体，再和 desc 用 AND 连接，用于追加不能被 OR 绕过的条件，例如软删除
+// 已有条件 a OR b 时结果为 ((a) OR (b)) AND (desc)
+func (c *Clause) WrapWhere(desc string, vars ...interface{}) {
+	c.groupConditions()
+	c.addCondition(condition{desc: desc, vars: vars})
+}
+
+// groupConditions 将已有的多个条件合并为一个整体，之后追加的条件不会改变它们之间的优先级
+func (c *Clause) groupConditions() {
+	if len(c.conditions) > 1 {
+		wrapped, wrappedVars := joinConditions(c.conditions)
+		c.conditions = []condition{{desc: wrapped, vars: wrappedVars}}
+	}
+}
+
+// addCondition 追加条件后重新生成 WHERE 子句，多个条件时每个条件都用括号包裹，避免条件内部的 OR 改变优先级
+func (c *Clause) addCondition(cond condition) {
+	c.conditions = append(c.conditions, cond)
+	if len(c.conditions) == 1 {
+		c.Set(WHERE, append([]interface{}{cond.desc}, cond.vars...)...)
+		return
+	}
+	desc, vars := joinConditions(c.conditions)
+	c.Set(WHERE, append([]interface{}{desc}, vars...)...)
+}
+
+// joinConditions 用 AND、OR 连接多个条件，每个条件都用括号包裹
+func joinConditions(conditions []condition) (string, []interface{}) {
+	var desc strings.Builder
+	var vars []interface{}
+	for i, cond := range conditions {
+		if i > 0 {
+			if cond.or {
+				desc.WriteString(" OR ")
+			} else {
+				desc.WriteString(" AND ")
+			}
+		}
+		desc.WriteString("(" + cond.desc + ")")
+		vars = append(vars, cond.vars...)
+	}
+	return desc.String(), vars
+}
+
+// AddJoin 追加一个 JOIN 子句，多个 JOIN 按追加的顺序拼接，例如 AddJoin("LEFT JOIN Order ON Order.UserID = User.ID")
+func (c *Clause) AddJoin(desc string, vars ...interface{}) {
+	c.joins = append(c.joins, condition{desc: desc, vars: vars})
+	var descs []string
+	var allVars []interface{}
+	for _, join := range c.joins {
+		descs = append(descs, join.desc)
+		allVars = append(allVars, join.vars...)
+	}
+	c.Set(JOIN, append([]interface{}{strings.Join(descs, " ")}, allVars...)...)
+}
+
+// Has 判断是否设置了某种子句
+func (c *Clause) Has(name Type) bool {
+	_, ok := c.sql[name]
+	return ok
+}
+
+// Clone 返回子句的副本，修改副本不会影响原来的子句
+func (c *Clause) Clone() Clause {
+	clone := Clause{
+		sql:        make(map[Type]string, len(c.sql)),
+		sqlVars:    make(map[Type][]interface{}, len(c.sqlVars)),
+		conditions: append([]condition(nil), c.conditions...),
+		joins:      append([]condition(nil), c.joins...),
+	}
+	for k, v := range c.sql {
+		clone.sql[k] = v
+	}
+	for k, v := range c.sqlVars {
+		clone.sqlVars[k] = v
+	}
+	return clone
+}
+
 // Build 方法根据传入的 Type 的顺序，构造出最终的 SQL 语句
 func (c *Clause) Build(orders ...Type) (string, []interface{}) {
 	var sqls []string
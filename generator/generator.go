@@ -3,6 +3,7 @@ package generator
 import (
 	"fmt"
 	"gamblerORM/log"
+	"sort"
 	"strings"
 )
 
@@ -26,6 +27,11 @@ func init() {
 	generators[UPDATE] = _update
 	generators[DELETE] = _delete
 	generators[COUNT] = _count
+	generators[RETURNING] = _returning
+	generators[OFFSET] = _offset
+	generators[GROUPBY] = _groupBy
+	generators[HAVING] = _having
+	generators[JOIN] = _join
 }
 
 // genBindVars 把一行的数据组合起来，用问号对应原来数据的位置
@@ -80,13 +86,30 @@ func _select(values ...interface{}) (string, []interface{}) {
 	return fmt.Sprintf("SELECT %v FROM %s", fields, tableName), []interface{}{}
 }
 
-// _limit
+// _limit 参数是字符串时原样拼接，用于 dialect 提供的无上限的 LIMIT
 func _limit(values ...interface{}) (string, []interface{}) {
+	if limit, ok := values[0].(string); ok {
+		return "LIMIT " + limit, []interface{}{}
+	}
 	// LIMIT $num
 	log.Infof("_limit -> values = %v\n", values)
 	return "LIMIT ?", values
 }
 
+// _offset
+func _offset(values ...interface{}) (string, []interface{}) {
+	// OFFSET $num
+	log.Infof("_offset -> values = %v\n", values)
+	return "OFFSET ?", values
+}
+
+// _join 第一个参数是完整的 JOIN 子句，例如 LEFT JOIN Order ON Order.UserID = User.ID，其余参数是占位符对应的值
+func _join(values ...interface{}) (string, []interface{}) {
+	desc, vars := values[0].(string), values[1:]
+	log.Infof("_join -> values = %v, desc = %v, vars = %v\n", values, desc, vars)
+	return desc, vars
+}
+
 // _where
 func _where(values ...interface{}) (string, []interface{}) {
 	// WHERE $desc
@@ -101,15 +124,35 @@ func _orderBy(values ...interface{}) (string, []interface{}) {
 	return fmt.Sprintf("ORDER BY %s", values[0]), []interface{}{}
 }
 
+// _groupBy
+func _groupBy(values ...interface{}) (string, []interface{}) {
+	log.Infof("_groupBy -> values = %v\n", values)
+	return fmt.Sprintf("GROUP BY %s", values[0]), []interface{}{}
+}
+
+// _having 和 _where 相同，第一个参数是条件，其余参数是条件中占位符对应的值
+func _having(values ...interface{}) (string, []interface{}) {
+	// HAVING $desc
+	desc, vars := values[0], values[1:]
+	log.Infof("_having -> values = %v, desc = %v, vars = %v\n", values, desc, vars)
+	return fmt.Sprintf("HAVING %s", desc), vars
+}
+
 // _update 第一个参数是表名(table)，第二个参数是 map 类型，表示待更新的键值对
+// 列按名称排序，保证同样的参数总是生成同样的 SQL，便于数据库缓存语句
 func _update(values ...interface{}) (string, []interface{}) {
 	tableName := values[0]
 	m := values[1].(map[string]interface{})
+	columns := make([]string, 0, len(m))
+	for k := range m {
+		columns = append(columns, k)
+	}
+	sort.Strings(columns)
 	var keys []string
 	var vars []interface{}
-	for k, v := range m {
+	for _, k := range columns {
 		keys = append(keys, k+" = ?")
-		vars = append(vars, v)
+		vars = append(vars, m[k])
 	}
 	log.Infof("_update -> keys = %v, vars = %v\n", keys, vars)
 	return fmt.Sprintf("UPDATE %s SET %s", tableName, strings.Join(keys, ", ")), vars
@@ -126,3 +169,13 @@ func _count(values ...interface{}) (string, []interface{}) {
 	log.Infof("_count -> values = %v\n", values)
 	return _select(values[0], []string{"count(*)"})
 }
+
+// _returning 参数是要返回的列名，用于在 INSERT 之后拿到数据库生成的值
+func _returning(values ...interface{}) (string, []interface{}) {
+	var fields []string
+	for _, v := range values {
+		fields = append(fields, fmt.Sprint(v))
+	}
+	log.Infof("_returning -> fields = %v\n", fields)
+	return fmt.Sprintf("RETURNING %s", strings.Join(fields, ", ")), []interface{}{}
+}
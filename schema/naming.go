@@ -0,0 +1,91 @@
+package schema
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NamingStrategy 定义结构体名、字段名到表名、列名的映射规则，Parse 时会调用它来决定表名和列名
+// 实现了 ITableName 的对象和 tag 中指定了 column 的字段不受命名规则影响
+type NamingStrategy interface {
+	TableName(name string) string  // 结构体名 -> 表名
+	ColumnName(name string) string // 字段名 -> 列名
+}
+
+// DefaultNaming 默认的命名规则，表名和列名与结构体名、字段名保持一致
+type DefaultNaming struct{}
+
+func (DefaultNaming) TableName(name string) string {
+	return name
+}
+
+func (DefaultNaming) ColumnName(name string) string {
+	return name
+}
+
+// SnakeCaseNaming 蛇形命名规则，例如 UserID -> user_id
+type SnakeCaseNaming struct{}
+
+func (SnakeCaseNaming) TableName(name string) string {
+	return toSnakeCase(name)
+}
+
+func (SnakeCaseNaming) ColumnName(name string) string {
+	return toSnakeCase(name)
+}
+
+// LowerNaming 小写命名规则，例如 UserID -> userid
+type LowerNaming struct{}
+
+func (LowerNaming) TableName(name string) string {
+	return strings.ToLower(name)
+}
+
+func (LowerNaming) ColumnName(name string) string {
+	return strings.ToLower(name)
+}
+
+// PrefixNaming 给表名加上统一的前缀，表名的其余部分和列名交给内部的命名规则处理
+// eg: PrefixNaming{Prefix: "t_", NamingStrategy: SnakeCaseNaming{}} 会把 UserInfo 映射为 t_user_info
+type PrefixNaming struct {
+	Prefix string
+	NamingStrategy
+}
+
+func (n PrefixNaming) TableName(name string) string {
+	if n.NamingStrategy != nil {
+		name = n.NamingStrategy.TableName(name)
+	}
+	return n.Prefix + name
+}
+
+func (n PrefixNaming) ColumnName(name string) string {
+	if n.NamingStrategy != nil {
+		return n.NamingStrategy.ColumnName(name)
+	}
+	return name
+}
+
+// toSnakeCase 将驼峰命名转换为蛇形命名，连续的大写字母视为一个单词，例如 HTTPServer -> http_server
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// 小写或数字后面的大写字母、以及缩写词结束时的大写字母是一个新单词的开始
+			if i > 0 && (!unicode.IsUpper(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]))) && runes[i-1] != '_' {
+				sb.WriteByte('_')
+			}
+			sb.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+var _ NamingStrategy = DefaultNaming{}
+var _ NamingStrategy = SnakeCaseNaming{}
+var _ NamingStrategy = LowerNaming{}
+var _ NamingStrategy = PrefixNaming{}
@@ -0,0 +1,28 @@
+package schema
+
+import "testing"
+
+func TestToSnakeCase(t *testing.T) {
+	cases := map[string]string{
+		"Name":       "name",
+		"UserID":     "user_id",
+		"HTTPServer": "http_server",
+		"CreatedAt":  "created_at",
+		"user_name":  "user_name",
+	}
+	for name, expect := range cases {
+		if got := toSnakeCase(name); got != expect {
+			t.Fatalf("expect %s, but got %s", expect, got)
+		}
+	}
+}
+
+func TestPrefixNaming(t *testing.T) {
+	naming := PrefixNaming{Prefix: "t_", NamingStrategy: SnakeCaseNaming{}}
+	if name := naming.TableName("UserInfo"); name != "t_user_info" {
+		t.Fatal("failed to add table prefix, got", name)
+	}
+	if name := naming.ColumnName("UserID"); name != "user_id" {
+		t.Fatal("failed to convert column name, got", name)
+	}
+}
@@ -0,0 +1,196 @@
+package schema
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"gamblerORM/dialect"
+	"gamblerORM/log"
+	"reflect"
+	"time"
+)
+
+// 关联关系的声明方式，关联字段不映射为列，由 Session.Preload 单独查询后填充：
+//
+//	type User struct {
+//		ID      int64    `gamblerORM:"primary_key"`
+//		Profile Profile  // has one，Profile.UserID 引用 User.ID
+//		Orders  []Order  `gamblerORM:"foreignKey:BuyerID"` // has many，Order.BuyerID 引用 User.ID
+//		Roles   []Role   `gamblerORM:"many2many:user_roles"` // many to many，中间表 user_roles 的 UserID、RoleID 分别引用 User.ID、Role.ID
+//	}
+//	type Order struct {
+//		ID      int64 `gamblerORM:"primary_key"`
+//		BuyerID int64
+//		Buyer   User  `gamblerORM:"foreignKey:BuyerID"` // belongs to，Order.BuyerID 引用 User.ID
+//	}
+//
+// foreignKey、references 和 associationReferences 的值都是字段名，joinForeignKey 和 joinReferences 的值是中间表的列名
+
+// RelationshipType 关联关系的类型
+type RelationshipType string
+
+const (
+	HasOne    RelationshipType = "has_one"
+	HasMany   RelationshipType = "has_many"
+	BelongsTo RelationshipType = "belongs_to"
+	Many2Many RelationshipType = "many2many"
+)
+
+// Relationship 代表结构体中的一个关联字段
+type Relationship struct {
+	Name        string           // 关联字段的字段名
+	StructIndex []int            // 关联字段在结构体中的索引路径，嵌入结构体中的关联字段有多级
+	Type        RelationshipType // 关联关系的类型
+	FieldType   reflect.Type     // 关联的结构体类型，字段是切片或指针时为元素的类型
+	ForeignKey  string           // 外键字段名，has one、has many 时在关联的结构体中，belongs to 时在本结构体中
+	References  string           // 外键引用的字段名，has one、has many、many2many 时在本结构体中，belongs to 时在关联的结构体中
+	// 以下只用于 many2many
+	AssociationReferences string  // 中间表引用的关联结构体的字段名
+	JoinTable             *Schema // 中间表，没有对应的结构体，Model 为 nil，第一列引用 References，第二列引用 AssociationReferences
+}
+
+// SettableValueOf 返回 dest 中该关联字段可以赋值的值，dest 是可寻址的结构体，路径上为 nil 的嵌入指针会被初始化
+func (rel *Relationship) SettableValueOf(dest reflect.Value) reflect.Value {
+	return FieldByIndex(dest, rel.StructIndex)
+}
+
+// parseRelationship 判断字段是否是关联字段，是则根据 tag 和默认规则推断出关联关系
+// 默认规则：
+// 1）字段是结构体切片时为 has many，外键是关联结构体中的 结构体名+主键名，例如 UserID
+// 2）字段是结构体时，如果本结构体中有外键字段则为 belongs to，外键默认是 字段名+ID，例如 BuyerID，否则为 has one
+// 3）tag 中有 many2many 时为多对多，中间表的两列默认是 结构体名+被引用的字段名，例如 UserID、RoleID，
+// 自引用时两列同名，引用关联结构体的一列改为 关联字段名+被引用的字段名，例如 Friends []User 的两列是 UserID、FriendsID
+func (schema *Schema) parseRelationship(modelType reflect.Type, p reflect.StructField, d dialect.Dialect, naming NamingStrategy) *Relationship {
+	fieldType, many := relationFieldType(p.Type)
+	if fieldType == nil {
+		return nil
+	}
+	settings := ParseTagSettings(p.Tag.Get("gamblerORM"))
+	rel := &Relationship{
+		Name:        p.Name,
+		StructIndex: p.Index,
+		FieldType:   fieldType,
+		ForeignKey:  settings["foreignkey"],
+		References:  settings["references"],
+	}
+	if !many {
+		// 外键在本结构体中说明是 belongs to
+		foreignKey := rel.ForeignKey
+		if foreignKey == "" {
+			references := rel.References
+			if references == "" {
+				references = "ID"
+			}
+			foreignKey = p.Name + references
+		}
+		if _, ok := modelType.FieldByName(foreignKey); ok {
+			rel.Type = BelongsTo
+			rel.ForeignKey = foreignKey
+			if rel.References == "" {
+				rel.References = "ID"
+			}
+			return rel
+		}
+		rel.Type = HasOne
+	} else {
+		rel.Type = HasMany
+	}
+	if rel.References == "" {
+		rel.References = "ID"
+		if len(schema.PrimaryFields) == 1 {
+			rel.References = schema.PrimaryFields[0].Name
+		}
+	}
+	if many && settings.Has("many2many") {
+		rel.Type = Many2Many
+		rel.ForeignKey = ""
+		rel.AssociationReferences = settings["associationreferences"]
+		if rel.AssociationReferences == "" {
+			rel.AssociationReferences = "ID"
+		}
+		rel.JoinTable = schema.parseJoinTable(modelType, rel, settings, d, naming)
+		if rel.JoinTable == nil {
+			return nil
+		}
+		return rel
+	}
+	if rel.ForeignKey == "" {
+		rel.ForeignKey = modelType.Name() + rel.References
+	}
+	return rel
+}
+
+// parseJoinTable 根据 many2many 关联字段构造中间表，两列组成联合主键，类型和被引用的字段相同
+func (schema *Schema) parseJoinTable(modelType reflect.Type, rel *Relationship, settings TagSettings, d dialect.Dialect, naming NamingStrategy) *Schema {
+	own := schema.GetField(rel.References)
+	if own == nil {
+		log.Errorf("many2many %s : %s has no field %s", rel.Name, modelType.Name(), rel.References)
+		return nil
+	}
+	p, ok := rel.FieldType.FieldByName(rel.AssociationReferences)
+	if !ok {
+		log.Errorf("many2many %s : %s has no field %s", rel.Name, rel.FieldType.Name(), rel.AssociationReferences)
+		return nil
+	}
+	join := newSchema(nil, settings["many2many"])
+	if join.Name == "" {
+		join.Name = naming.TableName(modelType.Name() + rel.FieldType.Name())
+	}
+	foreignKey := &Field{
+		Name:       modelType.Name() + own.Name,
+		Type:       own.Type,
+		Size:       own.Size,
+		PrimaryKey: true,
+	}
+	foreignKey.Column = settings["joinforeignkey"]
+	if foreignKey.Column == "" {
+		foreignKey.Column = naming.ColumnName(foreignKey.Name)
+	}
+	references := &Field{
+		Name:       rel.FieldType.Name() + p.Name,
+		Type:       dataTypeOf(p.Type, d),
+		PrimaryKey: true,
+	}
+	if references.Name == foreignKey.Name {
+		references.Name = rel.Name + p.Name
+	}
+	references.Column = settings["joinreferences"]
+	if references.Column == "" {
+		references.Column = naming.ColumnName(references.Name)
+	}
+	if references.Column == foreignKey.Column {
+		log.Errorf("many2many %s : join table %s has two columns named %s", rel.Name, join.Name, references.Column)
+		return nil
+	}
+	join.addField(foreignKey)
+	join.addField(references)
+	return join
+}
+
+// relationFieldType 返回关联字段对应的结构体类型，many 表示字段是切片，不是关联字段时返回 nil
+// time.Time 和实现了 sql.Scanner、driver.Valuer、IDataType 的自定义类型是普通的列，不是关联字段
+func relationFieldType(typ reflect.Type) (elem reflect.Type, many bool) {
+	if typ.Kind() == reflect.Slice {
+		typ, many = typ.Elem(), true
+	}
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct || typ == reflect.TypeOf(time.Time{}) || isCustomType(typ) {
+		return nil, false
+	}
+	return typ, many
+}
+
+// isCustomType 判断 typ 或者指向它的指针是否实现了 sql.Scanner、driver.Valuer 或 IDataType，这样的类型作为一列整体读写
+func isCustomType(typ reflect.Type) bool {
+	for _, iface := range []reflect.Type{
+		reflect.TypeOf((*sql.Scanner)(nil)).Elem(),
+		reflect.TypeOf((*driver.Valuer)(nil)).Elem(),
+		reflect.TypeOf((*IDataType)(nil)).Elem(),
+	} {
+		if typ.Implements(iface) || reflect.PtrTo(typ).Implements(iface) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+type Buyer struct {
+	ID      int64 `gamblerORM:"primary_key"`
+	Name    string
+	Card    *Card
+	Orders  []Order `gamblerORM:"foreignKey:BuyerID"`
+	Coupons []*Coupon
+}
+
+type Card struct {
+	ID      int64 `gamblerORM:"primary_key"`
+	BuyerID int64
+}
+
+type Order struct {
+	ID      int64 `gamblerORM:"primary_key"`
+	BuyerID int64
+	Buyer   Buyer
+}
+
+type Coupon struct {
+	Code    string `gamblerORM:"primary_key"`
+	BuyerID int64
+}
+
+func TestParse_Relationships(t *testing.T) {
+	schema := mustParse(t, &Buyer{}, TestDialect)
+	if len(schema.Fields) != 2 || len(schema.Relationships) != 3 {
+		t.Fatal("association fields should not be columns, got", schema.FieldNames)
+	}
+	expects := map[string]Relationship{
+		"Card":    {Name: "Card", StructIndex: []int{2}, Type: HasOne, FieldType: reflect.TypeOf(Card{}), ForeignKey: "BuyerID", References: "ID"},
+		"Orders":  {Name: "Orders", StructIndex: []int{3}, Type: HasMany, FieldType: reflect.TypeOf(Order{}), ForeignKey: "BuyerID", References: "ID"},
+		"Coupons": {Name: "Coupons", StructIndex: []int{4}, Type: HasMany, FieldType: reflect.TypeOf(Coupon{}), ForeignKey: "BuyerID", References: "ID"},
+	}
+	for name, expect := range expects {
+		if rel := schema.GetRelationship(name); rel == nil || !reflect.DeepEqual(*rel, expect) {
+			t.Fatalf("failed to parse relationship %s, got %+v", name, rel)
+		}
+	}
+	rel := mustParse(t, &Order{}, TestDialect).GetRelationship("Buyer")
+	if rel == nil || rel.Type != BelongsTo || rel.ForeignKey != "BuyerID" || rel.References != "ID" {
+		t.Fatalf("failed to parse belongs to, got %+v", rel)
+	}
+}
+
+type Member struct {
+	ID    int64  `gamblerORM:"primary_key"`
+	Roles []Role `gamblerORM:"many2many:member_roles;joinForeignKey:member_id"`
+}
+
+type Role struct {
+	ID   int64 `gamblerORM:"primary_key"`
+	Name string
+}
+
+func TestParse_Many2Many(t *testing.T) {
+	schema := mustParse(t, &Member{}, TestDialect)
+	rel := schema.GetRelationship("Roles")
+	if rel == nil || rel.Type != Many2Many || rel.References != "ID" || rel.AssociationReferences != "ID" {
+		t.Fatalf("failed to parse many2many, got %+v", rel)
+	}
+	join := rel.JoinTable
+	if join == nil || join.Name != "member_roles" || !reflect.DeepEqual(join.FieldNames, []string{"member_id", "RoleID"}) {
+		t.Fatalf("failed to parse join table, got %+v", join)
+	}
+	idType := schema.GetField("ID").Type
+	if len(join.PrimaryFields) != 2 || join.Fields[0].Type != idType || join.Fields[1].Type != idType {
+		t.Fatal("join table columns should be a composite primary key of the referenced types")
+	}
+}
+
+type Friend struct {
+	ID      int64    `gamblerORM:"primary_key"`
+	Friends []Friend `gamblerORM:"many2many:friendships"`
+	Blocked []Friend `gamblerORM:"many2many:blocks;joinForeignKey:FriendID;joinReferences:FriendID"`
+}
+
+func TestParse_Many2ManySelf(t *testing.T) {
+	schema := mustParse(t, &Friend{}, TestDialect)
+	// 自引用时引用关联结构体的一列使用关联字段名，避免两列同名
+	rel := schema.GetRelationship("Friends")
+	if rel == nil || rel.JoinTable == nil || !reflect.DeepEqual(rel.JoinTable.FieldNames, []string{"FriendID", "FriendsID"}) {
+		t.Fatalf("failed to parse self-referential join table, got %+v", rel)
+	}
+	// 通过 tag 指定了相同的列名时无法建表，不作为关联字段
+	if rel := schema.GetRelationship("Blocked"); rel != nil {
+		t.Fatalf("expect join table with duplicate columns to be rejected, got %+v", rel)
+	}
+}
@@ -1,10 +1,13 @@
 package schema
 
 import (
+	"database/sql"
+	"fmt"
 	"gamblerORM/dialect"
 	"gamblerORM/log"
 	"go/ast"
 	"reflect"
+	"time"
 )
 
 // 目标：实现 ORM 框架中最为核心的转换——对象(object)和表(table)的转换
@@ -23,38 +26,135 @@ import (
 
 // Field 代表数据库的一列的信息（不是数据）
 type Field struct {
-	Name string
-	Type string
-	Tag  string
+	Name           string // 结构体中的字段名
+	Column         string // 数据库中的列名
+	Type           string
+	Tag            string // 原始的 tag 字符串，解析结果保存在下面的字段中
+	PrimaryKey     bool
+	AutoIncrement  bool
+	NotNull        bool
+	Unique         bool
+	Default        string       // 默认值，原样写入建表语句，为空表示没有默认值
+	Size           int          // 字符串的长度，0 表示使用 dialect 的默认长度
+	Index          string       // 索引名，同名索引的列组成联合索引
+	StructIndex    []int        // 字段在结构体中的索引路径，嵌入结构体中的字段有多级
+	FieldType      reflect.Type // 字段的 Go 类型
+	Serializer     Serializer   // tag 中 serializer 指定的序列化方式，为 nil 表示直接读写
+	AutoCreateTime TimeUnit     // 插入时是否自动设置为当前时间，见 timestamp.go
+	AutoUpdateTime TimeUnit     // 插入和更新时是否自动设置为当前时间
+	Version        bool         // 是否是乐观锁的版本号，只能是整数字段
+}
+
+// ValueOf 返回 dest 中该字段的值，dest 是结构体，路径上的嵌入指针为 nil 时返回零值
+func (field *Field) ValueOf(dest reflect.Value) reflect.Value {
+	for i, x := range field.StructIndex {
+		if i > 0 && dest.Kind() == reflect.Ptr {
+			if dest.IsNil() {
+				return reflect.Zero(field.FieldType)
+			}
+			dest = dest.Elem()
+		}
+		dest = dest.Field(x)
+	}
+	return dest
+}
+
+// SettableValueOf 返回 dest 中该字段可以赋值的值，dest 是可寻址的结构体，路径上为 nil 的嵌入指针会被初始化
+func (field *Field) SettableValueOf(dest reflect.Value) reflect.Value {
+	return FieldByIndex(dest, field.StructIndex)
+}
+
+// DBValueOf 返回 dest 中该字段写入数据库的值，序列化字段会在执行 SQL 时编码
+func (field *Field) DBValueOf(dest reflect.Value) interface{} {
+	value := field.ValueOf(dest).Interface()
+	if field.Serializer != nil {
+		return serializerValue{serializer: field.Serializer, value: value}
+	}
+	return value
+}
+
+// ScanTarget 返回 rows.Scan 时接收该字段的参数，dest 是可寻址的结构体，序列化字段先接收数据库中的值再解码到字段中
+func (field *Field) ScanTarget(dest reflect.Value) interface{} {
+	value := field.SettableValueOf(dest)
+	if field.Serializer != nil {
+		return serializerScanner{serializer: field.Serializer, dest: value}
+	}
+	return value.Addr().Interface()
+}
+
+// FieldByIndex 和 reflect.Value.FieldByIndex 相同，但是会初始化路径上为 nil 的嵌入指针而不是 panic
+func FieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// ColumnDef 将列信息转换为 dialect 渲染建表语句所需的列定义
+func (field *Field) ColumnDef() *dialect.Column {
+	return &dialect.Column{
+		Name:          field.Column,
+		Type:          field.Type,
+		Size:          field.Size,
+		PrimaryKey:    field.PrimaryKey,
+		AutoIncrement: field.AutoIncrement,
+		NotNull:       field.NotNull,
+		Unique:        field.Unique,
+		Default:       field.Default,
+	}
 }
 
 // Schema 代表数据库的一张表的信息（不是数据）, 需要把其他对象构建成 schema 的样子
 type Schema struct {
-	Model      interface{}       // 被映射的对象
-	Name       string            //表名
-	Fields     []*Field          // 多个列
-	FieldNames []string          // 每个列的列名
-	fieldMap   map[string]*Field //存储列的信息，也就是 Field
+	Model              interface{}              // 被映射的对象
+	Name               string                   //表名
+	Fields             []*Field                 // 多个列
+	FieldNames         []string                 // 每个列的列名
+	PrimaryFields      []*Field                 // 主键列，联合主键时按字段顺序排列
+	AutoIncrementField *Field                   // 自增列，插入后由数据库生成值
+	DeletedAtField     *Field                   // 软删除列，见 softDeleteField
+	VersionField       *Field                   // 乐观锁的版本号列，tag 中有 version 的整数字段
+	Relationships      []*Relationship          // 关联字段，不映射为列
+	fieldMap           map[string]*Field        //存储列的信息，也就是 Field，key 是字段名
+	columnMap          map[string]*Field        // key 是列名
+	relationshipMap    map[string]*Relationship // key 是关联字段的字段名
 }
 
-// GetField 返回列信息 field，用于测试
+// GetField 根据字段名返回列信息 field
 func (schema *Schema) GetField(name string) *Field {
 	return schema.fieldMap[name]
 }
 
+// FieldByColumn 根据数据库中的列名返回列信息 field
+func (schema *Schema) FieldByColumn(column string) *Field {
+	return schema.columnMap[column]
+}
+
+// GetRelationship 根据字段名返回关联关系
+func (schema *Schema) GetRelationship(name string) *Relationship {
+	return schema.relationshipMap[name]
+}
+
 // RecordValues 返回 dest 对象的字段值，根据数据库中列的顺序，从对象中找到对应的值，按顺序平铺
-//INSERT 对应的 SQL 语句一般是这样的：
+// INSERT 对应的 SQL 语句一般是这样的：
 //
 //	INSERT INTO table_name(col1, col2, col3, ...) VALUES
 //	(A1, A2, A3, ...),
 //	(B1, B2, B3, ...),
 //	...
+//
 // RecordValues
 func (schema *Schema) RecordValues(dest interface{}) []interface{} {
 	destValue := reflect.Indirect(reflect.ValueOf(dest))
 	var fieldValues []interface{}
 	for _, field := range schema.Fields {
-		fieldValues = append(fieldValues, destValue.FieldByName(field.Name).Interface())
+		fieldValues = append(fieldValues, field.DBValueOf(destValue))
 	}
 	return fieldValues
 }
@@ -63,8 +163,34 @@ type ITableName interface {
 	TableName() string
 }
 
-// Parse 将任意对象解析为 Schema 实例
-func Parse(dest interface{}, d dialect.Dialect) *Schema {
+// IDataType 自定义类型实现该接口来指定列的数据类型，优先级高于 dialect 的推断，低于 tag 中的 type，例如
+//
+//	type Money struct{ Cents int64 }
+//	func (Money) DataType() string { return "bigint" }
+type IDataType interface {
+	DataType() string
+}
+
+// dataTypeOf 推断 Go 类型 typ 对应的数据类型，实现了 IDataType 的类型（或者指向它的指针）使用 DataType() 的返回值，否则交给 dialect 推断
+func dataTypeOf(typ reflect.Type, d dialect.Dialect) string {
+	elem := typ
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	if t, ok := reflect.New(elem).Interface().(IDataType); ok {
+		return t.DataType()
+	}
+	return d.DataTypeOf(reflect.Indirect(reflect.New(typ)))
+}
+
+// Parse 将任意对象解析为 Schema 实例，表名和列名与结构体名、字段名保持一致
+func Parse(dest interface{}, d dialect.Dialect) (*Schema, error) {
+	return ParseWithNaming(dest, d, DefaultNaming{})
+}
+
+// ParseWithNaming 将任意对象解析为 Schema 实例，表名和列名由 naming 决定
+// tag 中有未知或者无效的设置时返回错误，此时返回的 Schema 只包含出错之前解析的列，不能用于建表
+func ParseWithNaming(dest interface{}, d dialect.Dialect, naming NamingStrategy) (*Schema, error) {
 	// reflect.Indirect(v)函数用于获取v指向的值,如果v是nil指针，则Indirect返回零值。如果v不是指针，则Indirect返回v
 	// dest 是一个对象，例如 &User{} 结构体，使用 reflect.ValueOf() 可以拿到 User 结构体里面每个字段的值，再使用 type 拿到每个字段的类型，最后的 .Type() 是获取类型的，如 main.User
 	// 整体最后返回的是一个指针类型, 需要 reflect.Indirect() 获取指针指向的实例
@@ -75,38 +201,210 @@ func Parse(dest interface{}, d dialect.Dialect) *Schema {
 	var tableName string
 	t, ok := dest.(ITableName)
 	if !ok {
-		tableName = modelType.Name()
+		tableName = naming.TableName(modelType.Name())
 	} else {
 		tableName = t.TableName()
 	}
 
-	schema := &Schema{
-		Model:    dest,                    // 结构体
-		Name:     tableName,               // 例如 User, 作为表名
-		fieldMap: make(map[string]*Field), // 建立映射
+	schema := newSchema(dest, tableName)
+	// 关联字段的默认外键依赖主键，所以等所有列解析完之后再处理
+	fields, relationFields, err := schema.parseFields(modelType, nil, "", "", d, naming)
+	if err == nil {
+		err = schema.addFields(fields)
 	}
+	if err != nil {
+		return schema, fmt.Errorf("parse %s: %w", modelType.Name(), err)
+	}
+	schema.DeletedAtField = softDeleteField(schema)
+	for _, p := range relationFields {
+		rel := schema.parseRelationship(modelType, p, d, naming)
+		if rel == nil {
+			continue
+		}
+		schema.Relationships = append(schema.Relationships, rel)
+		schema.relationshipMap[rel.Name] = rel
+	}
+	return schema, nil
+}
+
+// parseFields 将结构体 modelType 的字段解析为列，返回这些列和其中的关联字段，tag 无效时返回错误
+// index 是 modelType 在 Model 中的索引路径，path 是字段名的前缀，prefix 是嵌入结构体的列名前缀，三者在解析 Model 本身时都为空
+// 匿名嵌入的结构体（值或指针）和 tag 中有 embedded 的结构体字段会被展开，它们的字段作为 Model 的列，例如
+//
+//	type Base struct {
+//		ID        int64 `gamblerORM:"primary_key"`
+//		CreatedAt time.Time
+//	}
+//	type User struct {
+//		Base
+//		Address Address `gamblerORM:"embedded;embeddedPrefix:addr_"` // Address.City 映射为 addr_City 列
+//	}
+//
+// 匿名嵌入的字段和 Go 一样提升为 Model 的字段，字段名不变，例如 ID；tag 中有 embedded 的字段不会提升，字段名带上路径，例如 Address.City
+func (schema *Schema) parseFields(modelType reflect.Type, index []int, path, prefix string, d dialect.Dialect, naming NamingStrategy) ([]*Field, []reflect.StructField, error) {
+	var fields []*Field
+	var relationFields []reflect.StructField
 	//  modelType 里面是 User 结构体里面每个字段的数据，NumField() 获取字段的数量
 	for i := 0; i < modelType.NumField(); i++ {
 		// 拿到每一个字段值
 		p := modelType.Field(i)
-		// 判断条件没懂
-		if !p.Anonymous && ast.IsExported(p.Name) {
-			// p.Name 即字段名，p.Type 即字段类型了，p.Tag 即额外的约束条件
-			field := &Field{
-				Name: p.Name,                                              // 字段名
-				Type: d.DataTypeOf(reflect.Indirect(reflect.New(p.Type))), // 类型
+		// 字段的索引路径，需要复制一份，避免和其他字段共用底层数组
+		p.Index = append(append([]int(nil), index...), i)
+		// 设置 field 的 tag 值,参数是 tag 的 key 值，tag 为 - 的字段不映射为列
+		tag := p.Tag.Get("gamblerORM")
+		if tag == "-" {
+			continue
+		}
+		settings := ParseTagSettings(tag)
+		// 展开嵌入的结构体，未导出的匿名结构体只能是值类型，否则无法初始化为 nil 的指针
+		if embedded := embeddedType(p, settings); embedded != nil && !settings.Has("serializer") {
+			embeddedPath := path
+			if !p.Anonymous {
+				embeddedPath += p.Name + "."
+			}
+			embeddedFields, embeddedRelations, err := schema.parseFields(embedded, p.Index, embeddedPath, prefix+settings["embeddedprefix"], d, naming)
+			if err != nil {
+				return nil, nil, err
+			}
+			fields = append(fields, embeddedFields...)
+			relationFields = append(relationFields, embeddedRelations...)
+			continue
+		}
+		// 未导出的字段不映射为列
+		if p.Anonymous || !ast.IsExported(p.Name) {
+			continue
+		}
+		// 结构体和结构体切片是关联字段，不映射为列，序列化字段除外
+		if elem, _ := relationFieldType(p.Type); elem != nil && !settings.Has("serializer") {
+			relationFields = append(relationFields, p)
+			continue
+		}
+		// p.Name 即字段名，p.Type 即字段类型了，p.Tag 即额外的约束条件
+		field := &Field{
+			Name:        path + p.Name,             // 字段名
+			Column:      naming.ColumnName(p.Name), // 列名
+			Tag:         tag,
+			StructIndex: p.Index,
+			FieldType:   p.Type,
+		}
+		if err := applyTagSettings(field, settings); err != nil {
+			return nil, nil, err
+		}
+		field.Column = prefix + field.Column
+		// 字段名为 CreatedAt、UpdatedAt 且类型支持时默认自动设置时间
+		if p.Name == "CreatedAt" && !settings.Has("autocreatetime") {
+			field.AutoCreateTime = defaultTimeUnit(field, "autoCreateTime")
+		}
+		if p.Name == "UpdatedAt" && !settings.Has("autoupdatetime") {
+			field.AutoUpdateTime = defaultTimeUnit(field, "autoUpdateTime")
+		}
+		// tag 中没有通过 type 指定类型时，由 dialect 根据 Go 类型推断，序列化字段使用编码后的值的类型
+		if field.Type == "" {
+			switch field.Serializer.(type) {
+			case nil:
+				field.Type = dataTypeOf(p.Type, d)
+			case JSONSerializer:
+				field.Type = d.JSONDataType()
+			default:
+				field.Type = d.DataTypeOf(reflect.ValueOf([]byte(nil)))
+			}
+		}
+		if field.Index == "" && settings.Has("index") {
+			field.Index = "idx_" + schema.Name + "_" + field.Column
+		}
+		// 一个 field 是一个列的信息，由 addFields 处理同名的字段后添加到 schema 中
+		fields = append(fields, field)
+	}
+	return fields, relationFields, nil
+}
+
+// addFields 按 Go 中字段提升的规则将 fields 添加到 schema 中：字段名或者列名相同的字段只保留嵌入层级最浅的一个，
+// 例如 Model 自身的 ID 会覆盖嵌入结构体中的 ID，层级相同时无法确定使用哪一个，返回错误
+func (schema *Schema) addFields(fields []*Field) error {
+	shadowed := make(map[*Field]bool)
+	for i, a := range fields {
+		for _, b := range fields[i+1:] {
+			if a.Name != b.Name && a.Column != b.Column {
+				continue
 			}
-			// 设置 field 的 tag 值,参数是 tag 的 key 值
-			if v, ok := p.Tag.Lookup("gamblerORM"); ok {
-				field.Tag = v
+			switch {
+			case len(a.StructIndex) < len(b.StructIndex):
+				shadowed[b] = true
+			case len(a.StructIndex) > len(b.StructIndex):
+				shadowed[a] = true
+			default:
+				return fmt.Errorf("field %s and %s have the same name or column", a.Name, b.Name)
 			}
-			// 一个 field 是一个列的信息，把每个列添加到 schema 中
-			schema.Fields = append(schema.Fields, field)
-			// 把每个字段名添加到字段名列表中，保存所有的列名
-			schema.FieldNames = append(schema.FieldNames, p.Name)
-			// 将列名和列的信息对应起来，列的信息包括 Field 里面的信息
-			schema.fieldMap[p.Name] = field
 		}
 	}
-	return schema
+	for _, field := range fields {
+		if !shadowed[field] {
+			schema.addField(field)
+		}
+	}
+	return nil
+}
+
+// softDeleteField 返回用于软删除的字段：字段名为 DeletedAt，类型为 *time.Time 或 sql.NullTime
+// 有软删除列的表删除记录时只设置删除时间，查询时只返回 DeletedAt 为 NULL 的记录，见 Session.Unscoped
+// 软删除列必须能够保存 NULL，time.Time 类型的 DeletedAt 会被当作普通的列
+func softDeleteField(schema *Schema) *Field {
+	field := schema.GetField("DeletedAt")
+	if field == nil {
+		return nil
+	}
+	switch field.FieldType {
+	case reflect.TypeOf((*time.Time)(nil)), reflect.TypeOf(sql.NullTime{}):
+		return field
+	}
+	log.Errorf("DeletedAt of %s should be *time.Time or sql.NullTime to enable soft delete", schema.Name)
+	return nil
+}
+
+// embeddedType 字段是需要展开的嵌入结构体时返回结构体的类型，否则返回 nil
+func embeddedType(p reflect.StructField, settings TagSettings) reflect.Type {
+	if !p.Anonymous && !settings.Has("embedded") {
+		return nil
+	}
+	typ := p.Type
+	if typ.Kind() == reflect.Ptr {
+		if !ast.IsExported(p.Name) {
+			return nil
+		}
+		typ = typ.Elem()
+	}
+	if elem, many := relationFieldType(typ); elem == nil || many {
+		return nil
+	}
+	return typ
+}
+
+// newSchema 创建一个空的 Schema，之后通过 addField 添加列
+func newSchema(model interface{}, name string) *Schema {
+	return &Schema{
+		Model:           model,                   // 结构体
+		Name:            name,                    // 例如 User, 作为表名
+		fieldMap:        make(map[string]*Field), // 建立映射
+		columnMap:       make(map[string]*Field),
+		relationshipMap: make(map[string]*Relationship),
+	}
+}
+
+// addField 将一列添加到 schema 中
+func (schema *Schema) addField(field *Field) {
+	schema.Fields = append(schema.Fields, field)
+	// 保存所有的列名
+	schema.FieldNames = append(schema.FieldNames, field.Column)
+	// 将字段名、列名和列的信息对应起来，列的信息包括 Field 里面的信息
+	schema.fieldMap[field.Name] = field
+	schema.columnMap[field.Column] = field
+	if field.PrimaryKey {
+		schema.PrimaryFields = append(schema.PrimaryFields, field)
+	}
+	if field.AutoIncrement && schema.AutoIncrementField == nil {
+		schema.AutoIncrementField = field
+	}
+	if field.Version && schema.VersionField == nil {
+		schema.VersionField = field
+	}
 }
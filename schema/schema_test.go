@@ -2,6 +2,7 @@ package schema
 
 import (
 	"gamblerORM/dialect"
+	"reflect"
 	"testing"
 )
 
@@ -12,8 +13,18 @@ type User struct {
 
 var TestDialect, _ = dialect.GetDialect("sqlite3")
 
+// mustParse 解析 dest，出错时终止测试
+func mustParse(t *testing.T, dest interface{}, d dialect.Dialect) *Schema {
+	t.Helper()
+	schema, err := Parse(dest, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
 func TestParse(t *testing.T) {
-	schema := Parse(&User{}, TestDialect)
+	schema := mustParse(t, &User{}, TestDialect)
 	if schema.Name != "User" || len(schema.Fields) != 2 {
 		t.Fatal("failed to parse User struct")
 	}
@@ -23,7 +34,7 @@ func TestParse(t *testing.T) {
 }
 
 func TestSchema_RecordValues(t *testing.T) {
-	schema := Parse(&User{}, TestDialect)
+	schema := mustParse(t, &User{}, TestDialect)
 	values := schema.RecordValues(&User{"Tom", 18})
 
 	name := values[0].(string)
@@ -44,8 +55,130 @@ func (u *UserTest) TableName() string {
 }
 
 func TestSchema_TableName(t *testing.T) {
-	schema := Parse(&UserTest{}, TestDialect)
+	schema := mustParse(t, &UserTest{}, TestDialect)
 	if schema.Name != "ns_user_test" || len(schema.Fields) != 2 {
 		t.Fatal("failed to parse User struct")
 	}
 }
+
+type UserInfo struct {
+	UserID   int
+	NickName string `gamblerORM:"column:nick;PRIMARY KEY"`
+}
+
+func TestParseWithNaming(t *testing.T) {
+	schema, err := ParseWithNaming(&UserInfo{}, TestDialect, SnakeCaseNaming{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema.Name != "user_info" {
+		t.Fatal("failed to convert table name, got", schema.Name)
+	}
+	if field := schema.GetField("UserID"); field == nil || field.Column != "user_id" {
+		t.Fatal("failed to convert column name")
+	}
+	// tag 中指定的列名优先于命名规则
+	field := schema.FieldByColumn("nick")
+	if field == nil || field.Name != "NickName" || !field.PrimaryKey {
+		t.Fatal("failed to parse column tag")
+	}
+	if schema.FieldNames[0] != "user_id" || schema.FieldNames[1] != "nick" {
+		t.Fatal("failed to collect column names, got", schema.FieldNames)
+	}
+}
+
+type Base struct {
+	ID   int64 `gamblerORM:"primary_key"`
+	Note string
+}
+
+type Address struct {
+	City   string
+	Street string `gamblerORM:"column:street"`
+}
+
+type Shop struct {
+	*Base
+	Name    string
+	Address Address `gamblerORM:"embedded;embeddedPrefix:addr_"`
+}
+
+func TestParse_Embedded(t *testing.T) {
+	schema := mustParse(t, &Shop{}, TestDialect)
+	if !reflect.DeepEqual(schema.FieldNames, []string{"ID", "Note", "Name", "addr_City", "addr_street"}) {
+		t.Fatal("failed to flatten embedded structs, got", schema.FieldNames)
+	}
+	if len(schema.PrimaryFields) != 1 || schema.PrimaryFields[0].Name != "ID" {
+		t.Fatal("failed to parse primary key of embedded struct")
+	}
+	// 不是匿名嵌入的字段名带上路径
+	if street := schema.GetField("Address.Street"); street == nil || !reflect.DeepEqual(street.StructIndex, []int{2, 1}) {
+		t.Fatal("failed to record index path, got", street)
+	}
+	// 嵌入指针为 nil 时取零值
+	values := schema.RecordValues(&Shop{Name: "Tom", Address: Address{City: "Beijing"}})
+	if !reflect.DeepEqual(values, []interface{}{int64(0), "", "Tom", "Beijing", ""}) {
+		t.Fatal("failed to get values through nested path, got", values)
+	}
+	shop := &Shop{}
+	schema.GetField("ID").SettableValueOf(reflect.ValueOf(shop).Elem()).SetInt(1)
+	if shop.Base == nil || shop.ID != 1 {
+		t.Fatal("failed to set value through nil embedded pointer")
+	}
+}
+
+type Branch struct {
+	Base
+	ID   int64   `gamblerORM:"primary_key"`
+	Home Address `gamblerORM:"embedded;embeddedPrefix:home_"`
+	Work Address `gamblerORM:"embedded;embeddedPrefix:work_"`
+}
+
+type Twins struct {
+	Home Address `gamblerORM:"embedded"`
+	Work Address `gamblerORM:"embedded"`
+}
+
+func TestParse_EmbeddedShadow(t *testing.T) {
+	schema := mustParse(t, &Branch{}, TestDialect)
+	// Branch.ID 覆盖了 Base.ID，同一类型嵌入两次时通过前缀区分
+	if !reflect.DeepEqual(schema.FieldNames, []string{"Note", "ID", "home_City", "home_street", "work_City", "work_street"}) {
+		t.Fatal("failed to shadow embedded fields, got", schema.FieldNames)
+	}
+	if len(schema.PrimaryFields) != 1 || !reflect.DeepEqual(schema.GetField("ID").StructIndex, []int{1}) {
+		t.Fatal("outer field should shadow embedded field, got", schema.GetField("ID"))
+	}
+	if home, work := schema.GetField("Home.City"), schema.GetField("Work.City"); home.Column != "home_City" || work.Column != "work_City" {
+		t.Fatal("failed to distinguish fields embedded twice, got", home, work)
+	}
+	if _, err := Parse(&Twins{}, TestDialect); err == nil {
+		t.Fatal("expect error for duplicate columns at the same depth")
+	}
+}
+
+type Money struct {
+	Cents int64
+}
+
+func (Money) DataType() string {
+	return "decimal(20,2)"
+}
+
+type Wallet struct {
+	ID      int64 `gamblerORM:"primary_key"`
+	Balance Money
+	Credit  *Money `gamblerORM:"type:numeric"`
+}
+
+func TestParse_DataType(t *testing.T) {
+	schema := mustParse(t, &Wallet{}, TestDialect)
+	if len(schema.Fields) != 3 || len(schema.Relationships) != 0 {
+		t.Fatal("custom types should be columns instead of associations")
+	}
+	if typ := schema.GetField("Balance").Type; typ != "decimal(20,2)" {
+		t.Fatal("failed to use DataType(), got", typ)
+	}
+	if typ := schema.GetField("Credit").Type; typ != "numeric" {
+		t.Fatal("tag type should take precedence over DataType(), got", typ)
+	}
+}
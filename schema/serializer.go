@@ -0,0 +1,113 @@
+package schema
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// 序列化字段：tag 中设置了 serializer 的字段写入数据库前先编码，读取后再解码，适合结构体、map、切片等没有对应数据类型的字段，例如
+//
+//	type User struct {
+//		Tags    []string          `gamblerORM:"serializer:json"`
+//		Profile map[string]string `gamblerORM:"serializer:gob"`
+//	}
+//
+// json 编码为字符串，列的类型由 dialect 的 JSONDataType 决定，gob 编码为字节切片，列的类型和 []byte 相同
+
+// Serializer 定义字段值和数据库中的值之间的转换
+type Serializer interface {
+	Marshal(v interface{}) (interface{}, error) // 将字段的值编码为写入数据库的值
+	Unmarshal(data []byte, v interface{}) error // 将数据库中的值解码到 v 中，v 是字段的指针
+}
+
+// JSONSerializer 使用 encoding/json 编码为字符串
+type JSONSerializer struct{}
+
+func (JSONSerializer) Marshal(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+func (JSONSerializer) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// GobSerializer 使用 encoding/gob 编码为字节切片
+type GobSerializer struct{}
+
+func (GobSerializer) Marshal(v interface{}) (interface{}, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(v)
+	return buf.Bytes(), err
+}
+
+func (GobSerializer) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// serializers 保存可以在 tag 中使用的序列化方式
+var serializers = map[string]Serializer{
+	"json": JSONSerializer{},
+	"gob":  GobSerializer{},
+}
+
+// RegisterSerializer 注册序列化方式，之后可以在 tag 中通过 serializer:name 使用
+func RegisterSerializer(name string, serializer Serializer) {
+	serializers[name] = serializer
+}
+
+// GetSerializer 根据名称返回序列化方式
+func GetSerializer(name string) (serializer Serializer, ok bool) {
+	serializer, ok = serializers[name]
+	return
+}
+
+// serializerValue 在执行 SQL 时才编码字段的值，编码失败的错误由 database/sql 返回
+// 值为 nil 的 map、切片和指针写入 NULL，读取时再还原为 nil
+type serializerValue struct {
+	serializer Serializer
+	value      interface{}
+}
+
+func (v serializerValue) Value() (driver.Value, error) {
+	rv := reflect.ValueOf(v.value)
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return nil, nil
+		}
+	}
+	return v.serializer.Marshal(v.value)
+}
+
+// serializerScanner 将查询结果解码到字段中，NULL 会把字段置为零值
+type serializerScanner struct {
+	serializer Serializer
+	dest       reflect.Value // 可以赋值的字段
+}
+
+func (s serializerScanner) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		s.dest.Set(reflect.Zero(s.dest.Type()))
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("serializer.go : cannot unmarshal %T into %s", src, s.dest.Type())
+	}
+	// 先解码到新的值中，避免和字段中原有的值合并
+	value := reflect.New(s.dest.Type())
+	if err := s.serializer.Unmarshal(data, value.Interface()); err != nil {
+		return err
+	}
+	s.dest.Set(value.Elem())
+	return nil
+}
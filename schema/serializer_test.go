@@ -0,0 +1,65 @@
+package schema
+
+import (
+	"database/sql/driver"
+	"gamblerORM/dialect"
+	"reflect"
+	"testing"
+)
+
+type Setting struct {
+	ID     int64             `gamblerORM:"primary_key"`
+	Tags   []string          `gamblerORM:"serializer:json"`
+	Theme  Theme             `gamblerORM:"serializer:json"`
+	Extras map[string]string `gamblerORM:"serializer:gob"`
+}
+
+type Theme struct {
+	Color string
+	Dark  bool
+}
+
+func TestParse_Serializer(t *testing.T) {
+	cases := []struct {
+		Dialect string
+		Expect  []string
+	}{
+		{"sqlite3", []string{"text", "text", "blob"}},
+		{"mysql", []string{"json", "json", "blob"}},
+		{"postgres", []string{"jsonb", "jsonb", "bytea"}},
+	}
+	for _, c := range cases {
+		d, _ := dialect.GetDialect(c.Dialect)
+		schema := mustParse(t, &Setting{}, d)
+		if len(schema.Fields) != 4 || len(schema.Relationships) != 0 {
+			t.Fatal("serializer fields should be columns, got", schema.FieldNames)
+		}
+		var types []string
+		for _, name := range []string{"Tags", "Theme", "Extras"} {
+			types = append(types, schema.GetField(name).Type)
+		}
+		if !reflect.DeepEqual(types, c.Expect) {
+			t.Fatalf("%s: expect %v, but got %v", c.Dialect, c.Expect, types)
+		}
+	}
+}
+
+func TestField_Serializer(t *testing.T) {
+	schema := mustParse(t, &Setting{}, TestDialect)
+	setting := &Setting{Tags: []string{"a", "b"}, Theme: Theme{Color: "red"}}
+	values := schema.RecordValues(setting)
+	v, err := values[1].(driver.Valuer).Value()
+	if err != nil || v != `["a","b"]` {
+		t.Fatal("failed to marshal json, got", v, err)
+	}
+
+	var dest Setting
+	field := schema.GetField("Theme")
+	scanner := field.ScanTarget(reflect.ValueOf(&dest).Elem()).(interface{ Scan(interface{}) error })
+	if err := scanner.Scan([]byte(`{"Color":"blue","Dark":true}`)); err != nil || dest.Theme != (Theme{"blue", true}) {
+		t.Fatal("failed to unmarshal json, got", dest.Theme, err)
+	}
+	if err := scanner.Scan(nil); err != nil || dest.Theme != (Theme{}) {
+		t.Fatal("NULL should reset the field to zero value, got", dest.Theme, err)
+	}
+}
@@ -0,0 +1,109 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// tag 的语法：`gamblerORM:"primary_key;auto_increment;size:64;default:0;column:user_id"`
+// 1、各个设置之间用 ; 分隔，带值的设置使用 key:value 的形式
+// 2、key 不区分大小写，空格等同于下划线，所以旧写法 `gamblerORM:"PRIMARY KEY"` 依然有效
+// 3、整个 tag 为 - 时表示忽略该字段，不映射为列
+// 4、关联字段使用 foreignKey、references、many2many 等设置指定外键和中间表，见 relationship.go
+// 5、结构体字段使用 embedded 展开为多列，embeddedPrefix 指定这些列的列名前缀，见 schema.go 中的 parseFields
+// 6、serializer:json 或 serializer:gob 将字段编码后存为一列，见 serializer.go
+// 7、autoCreateTime、autoUpdateTime 在插入、更新时自动设置时间，见 timestamp.go
+// 8、version 将整数字段作为乐观锁的版本号，Save 和 Updates 只更新版本号没有变化的记录
+// 未知的设置和无效的值会使 Parse 返回错误，而不是被忽略
+
+// TagSettings 是解析后的 tag，key 统一为小写下划线形式
+type TagSettings map[string]string
+
+// ParseTagSettings 将 tag 字符串解析为 TagSettings
+func ParseTagSettings(tag string) TagSettings {
+	settings := TagSettings{}
+	for _, part := range strings.Split(tag, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		// 只按第一个 : 切分，default 的值中可能还会出现 :
+		kv := strings.SplitN(part, ":", 2)
+		key := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(kv[0])), " ", "_")
+		if len(kv) == 2 {
+			settings[key] = strings.TrimSpace(kv[1])
+		} else {
+			settings[key] = ""
+		}
+	}
+	return settings
+}
+
+// Has 判断是否包含某个设置，aliases 用于兼容不同的写法，例如 autoincrement 和 auto_increment
+func (t TagSettings) Has(aliases ...string) bool {
+	for _, key := range aliases {
+		if _, ok := t[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// applyTagSettings 将 tag 中的设置填充到 field 中，遇到未知或者无效的设置时返回错误，避免建表时悄悄丢掉约束
+func applyTagSettings(field *Field, settings TagSettings) error {
+	for key, value := range settings {
+		switch key {
+		case "primary_key", "primarykey":
+			field.PrimaryKey = true
+		case "auto_increment", "autoincrement":
+			field.AutoIncrement = true
+		case "not_null", "notnull":
+			field.NotNull = true
+		case "unique":
+			field.Unique = true
+		case "default":
+			field.Default = value
+		case "size":
+			size, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid size %q of field %s", value, field.Name)
+			}
+			field.Size = size
+		case "index":
+			// 没有指定索引名时由 Parse 根据表名和列名生成
+			field.Index = value
+		case "column":
+			field.Column = value
+		case "type":
+			field.Type = value
+		case "serializer":
+			serializer, ok := GetSerializer(value)
+			if !ok {
+				return fmt.Errorf("unknown serializer %q of field %s", value, field.Name)
+			}
+			field.Serializer = serializer
+		case "version":
+			switch field.FieldType.Kind() {
+			case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+				field.Version = true
+			default:
+				return fmt.Errorf("version field %s should be an integer", field.Name)
+			}
+		case "autocreatetime", "autoupdatetime":
+			unit, ok := parseTimeUnit(field.FieldType, value)
+			if !ok {
+				return fmt.Errorf("invalid %s %q of field %s", key, value, field.Name)
+			}
+			if key == "autocreatetime" {
+				field.AutoCreateTime = unit
+			} else {
+				field.AutoUpdateTime = unit
+			}
+		default:
+			return fmt.Errorf("unknown tag setting %q of field %s", key, field.Name)
+		}
+	}
+	return nil
+}
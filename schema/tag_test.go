@@ -0,0 +1,123 @@
+package schema
+
+import (
+	"testing"
+	"time"
+)
+
+type Product struct {
+	ID     int64  `gamblerORM:"primary_key;auto_increment"`
+	Code   string `gamblerORM:"size:64;unique;not_null;index"`
+	Price  int    `gamblerORM:"default:0;index:idx_price"`
+	Remark string `gamblerORM:"type:varchar(32);column:note"`
+	Cache  string `gamblerORM:"-"`
+}
+
+func TestParseTagSettings(t *testing.T) {
+	settings := ParseTagSettings("PRIMARY KEY; size:64;default:'a:b'")
+	if !settings.Has("primary_key") || settings["size"] != "64" || settings["default"] != "'a:b'" {
+		t.Fatal("failed to parse tag settings, got", settings)
+	}
+}
+
+func TestParse_TagSettings(t *testing.T) {
+	schema := mustParse(t, &Product{}, TestDialect)
+	if len(schema.Fields) != 4 || schema.GetField("Cache") != nil {
+		t.Fatal("failed to ignore field with tag -")
+	}
+	id := schema.GetField("ID")
+	if !id.PrimaryKey || !id.AutoIncrement {
+		t.Fatal("failed to parse primary key and auto increment")
+	}
+	code := schema.GetField("Code")
+	if code.Size != 64 || !code.Unique || !code.NotNull || code.Index != "idx_Product_Code" {
+		t.Fatal("failed to parse constraints of Code, got", code)
+	}
+	price := schema.GetField("Price")
+	if price.Default != "0" || price.Index != "idx_price" {
+		t.Fatal("failed to parse constraints of Price, got", price)
+	}
+	remark := schema.FieldByColumn("note")
+	if remark == nil || remark.Type != "varchar(32)" {
+		t.Fatal("failed to parse type and column of Remark")
+	}
+}
+
+type Article struct {
+	ID        int64 `gamblerORM:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time  `gamblerORM:"autoUpdateTime:false"`
+	LoginAt   *time.Time `gamblerORM:"autoCreateTime"`
+	Created   int64      `gamblerORM:"autoCreateTime:nano"`
+	Edited    int64      `gamblerORM:"autoUpdateTime:milli"`
+}
+
+func TestParse_AutoTime(t *testing.T) {
+	schema := mustParse(t, &Article{}, TestDialect)
+	cases := []struct {
+		name           string
+		create, update TimeUnit
+	}{
+		{"CreatedAt", NativeTime, NoAutoTime},
+		{"UpdatedAt", NoAutoTime, NoAutoTime},
+		{"LoginAt", NativeTime, NoAutoTime},
+		{"Created", UnixNanosecond, NoAutoTime},
+		{"Edited", NoAutoTime, UnixMillisecond},
+	}
+	for _, c := range cases {
+		field := schema.GetField(c.name)
+		if field.AutoCreateTime != c.create || field.AutoUpdateTime != c.update {
+			t.Fatalf("failed to parse auto time of %s, got %d %d", c.name, field.AutoCreateTime, field.AutoUpdateTime)
+		}
+	}
+	now := time.Unix(1700000000, 123456789)
+	if v := schema.GetField("Edited").TimeValue(UnixMillisecond, now).Int(); v != 1700000000123 {
+		t.Fatal("failed to convert time to unix milliseconds, got", v)
+	}
+	if v := schema.GetField("LoginAt").TimeValue(NativeTime, now).Interface().(*time.Time); !v.Equal(now) {
+		t.Fatal("failed to convert time to *time.Time, got", v)
+	}
+}
+
+func TestParse_Version(t *testing.T) {
+	type Item struct {
+		ID      int64 `gamblerORM:"primary_key"`
+		Version int   `gamblerORM:"version"`
+		Name    string
+	}
+	schema := mustParse(t, &Item{}, TestDialect)
+	if schema.VersionField != schema.GetField("Version") || schema.GetField("Name").Version {
+		t.Fatal("failed to parse version field, got", schema.VersionField)
+	}
+}
+
+func TestParse_InvalidTag(t *testing.T) {
+	cases := []interface{}{
+		&struct {
+			Created int32 `gamblerORM:"autoCreateTime"`
+		}{},
+		&struct {
+			Created int `gamblerORM:"autoCreateTime:milli"`
+		}{},
+		&struct {
+			Updated uint32 `gamblerORM:"autoUpdateTime:nano"`
+		}{},
+		&struct {
+			Price int `gamblerORM:"check:Price > 0"`
+		}{},
+		&struct {
+			Name string `gamblerORM:"szie:64"`
+		}{},
+		&struct {
+			Name string `gamblerORM:"size:big"`
+		}{},
+		&struct {
+			Name string `gamblerORM:"version"`
+		}{},
+	}
+	for _, c := range cases {
+		if _, err := Parse(c, TestDialect); err == nil {
+			t.Fatalf("expect error for invalid tag of %T", c)
+		}
+	}
+}
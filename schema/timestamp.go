@@ -0,0 +1,100 @@
+package schema
+
+import (
+	"database/sql"
+	"gamblerORM/log"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// 自动时间戳：插入记录时设置创建时间和更新时间，更新记录时刷新更新时间，例如
+//
+//	type User struct {
+//		CreatedAt time.Time // 字段名为 CreatedAt、UpdatedAt 时不需要 tag
+//		UpdatedAt int64     `gamblerORM:"autoUpdateTime:milli"` // 整数字段保存 Unix 时间戳，默认单位为秒
+//		LoginAt   time.Time `gamblerORM:"autoCreateTime"`
+//		Created   int64     `gamblerORM:"autoCreateTime:nano"`
+//	}
+//
+// autoCreateTime:false、autoUpdateTime:false 可以关闭 CreatedAt、UpdatedAt 的默认行为
+
+// TimeUnit 自动时间戳的保存方式
+type TimeUnit int
+
+const (
+	NoAutoTime      TimeUnit = iota // 不自动设置
+	NativeTime                      // time.Time、*time.Time 和 sql.NullTime 字段直接保存时间
+	UnixSecond                      // 整数字段保存秒级的 Unix 时间戳
+	UnixMillisecond                 // 整数字段保存毫秒级的 Unix 时间戳
+	UnixNanosecond                  // 整数字段保存纳秒级的 Unix 时间戳
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	timePtrType  = reflect.TypeOf((*time.Time)(nil))
+	nullTimeType = reflect.TypeOf(sql.NullTime{})
+)
+
+// parseTimeUnit 根据字段类型和 tag 的值返回自动时间戳的保存方式，类型或者值不支持时 ok 为 false
+// 时间类型的字段忽略 tag 的值，整数字段的值可以是空（秒）、milli 或 nano，其中 milli 和 nano 只能用于 int64 和 uint64
+func parseTimeUnit(typ reflect.Type, value string) (unit TimeUnit, ok bool) {
+	value = strings.ToLower(value)
+	if value == "false" {
+		return NoAutoTime, true
+	}
+	switch typ {
+	case timeType, timePtrType, nullTimeType:
+		return NativeTime, true
+	}
+	// 32 位整数保存秒在 2038 年之后会溢出，毫秒和纳秒只能保存在 64 位整数中，int 和 uint 的长度和平台有关
+	switch typ.Kind() {
+	case reflect.Int, reflect.Uint:
+		if value == "" || value == "true" {
+			return UnixSecond, true
+		}
+	case reflect.Int64, reflect.Uint64:
+		switch value {
+		case "", "true":
+			return UnixSecond, true
+		case "milli":
+			return UnixMillisecond, true
+		case "nano":
+			return UnixNanosecond, true
+		}
+	}
+	return NoAutoTime, false
+}
+
+// defaultTimeUnit 返回 CreatedAt、UpdatedAt 默认的保存方式，其他类型的字段不自动设置，整数的长度不够时记录错误
+func defaultTimeUnit(field *Field, key string) TimeUnit {
+	unit, ok := parseTimeUnit(field.FieldType, "")
+	if !ok {
+		switch field.FieldType.Kind() {
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+			log.Errorf("invalid %s of field %s: %s cannot hold a unix timestamp", key, field.Name, field.FieldType)
+		}
+	}
+	return unit
+}
+
+// TimeValue 返回字段按 unit 保存 now 时的值，类型和字段的类型相同
+func (field *Field) TimeValue(unit TimeUnit, now time.Time) reflect.Value {
+	switch unit {
+	case NativeTime:
+		switch field.FieldType {
+		case timePtrType:
+			return reflect.ValueOf(&now)
+		case nullTimeType:
+			return reflect.ValueOf(sql.NullTime{Time: now, Valid: true})
+		}
+		return reflect.ValueOf(now)
+	case UnixSecond:
+		return reflect.ValueOf(now.Unix()).Convert(field.FieldType)
+	case UnixMillisecond:
+		return reflect.ValueOf(now.UnixNano() / int64(time.Millisecond)).Convert(field.FieldType)
+	case UnixNanosecond:
+		return reflect.ValueOf(now.UnixNano()).Convert(field.FieldType)
+	}
+	return reflect.Zero(field.FieldType)
+}
@@ -0,0 +1,59 @@
+package session
+
+import (
+	"database/sql"
+	"fmt"
+	"gamblerORM/generator"
+)
+
+// 用于放置聚合查询相关的代码，和 Count 一样复用链式调用设置的 WHERE 条件，例如
+// total, err := s.Model(&Order{}).Where("UserID = ?", 1).Sum("Amount")
+
+// aggregate 执行 SELECT fn(column) FROM table WHERE ...，将结果写入 dest
+func (s *Session) aggregate(fn string, column string, dest interface{}) error {
+	expr := fmt.Sprintf("%s(%s)", fn, s.qualifiedColumnOf(column))
+	s.clause.Set(generator.SELECT, s.quote(s.RefTable().Name), []string{expr})
+	s.softDeleteScope()
+	sql, vars := s.clause.Build(generator.SELECT, generator.JOIN, generator.WHERE)
+	if err := s.stmtErr(); err != nil {
+		s.Clear()
+		return err
+	}
+	// 最终的结果只是一条数据不是多条
+	return s.Raw(sql, vars...).QueryRow().Scan(dest)
+}
+
+// Sum 返回 column 列的和，没有符合条件的记录时返回 0
+func (s *Session) Sum(column string) (float64, error) {
+	var result sql.NullFloat64
+	if err := s.aggregate("SUM", column, &result); err != nil {
+		return 0, err
+	}
+	return result.Float64, nil
+}
+
+// Avg 返回 column 列的平均值，没有符合条件的记录时返回 0
+func (s *Session) Avg(column string) (float64, error) {
+	var result sql.NullFloat64
+	if err := s.aggregate("AVG", column, &result); err != nil {
+		return 0, err
+	}
+	return result.Float64, nil
+}
+
+// Min 将 column 列的最小值写入 dest，dest 的类型和列的类型对应
+// 没有符合条件的记录时结果是 NULL，需要 dest 能够接收 NULL，例如 sql.NullInt64 或者指针的指针
+func (s *Session) Min(column string, dest interface{}) error {
+	return s.aggregate("MIN", column, dest)
+}
+
+// Max 将 column 列的最大值写入 dest，用法和 Min 相同
+func (s *Session) Max(column string, dest interface{}) error {
+	return s.aggregate("MAX", column, dest)
+}
+
+// Pluck 查询 column 这一列的值写入切片 dest，例如 s.Model(&User{}).Where("Age > ?", 18).Pluck("Name", &names)
+func (s *Session) Pluck(column string, dest interface{}) error {
+	s.selects = []string{column}
+	return s.Scan(dest)
+}
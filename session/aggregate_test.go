@@ -0,0 +1,52 @@
+package session
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestSession_Aggregate(t *testing.T) {
+	s := testOrderInit(t, 4)
+	sum, err := s.Model(&Order{}).Where("Amount > ?", 10).Sum("Amount")
+	if err != nil || sum != 90 {
+		t.Fatal("failed to sum, got", sum, err)
+	}
+	avg, err := s.Model(&Order{}).Avg("Amount")
+	if err != nil || avg != 25 {
+		t.Fatal("failed to avg, got", avg, err)
+	}
+	var min, max int
+	if err := s.Model(&Order{}).Min("Amount", &min); err != nil || min != 10 {
+		t.Fatal("failed to min, got", min, err)
+	}
+	if err := s.Model(&Order{}).Max("Amount", &max); err != nil || max != 40 {
+		t.Fatal("failed to max, got", max, err)
+	}
+	var none sql.NullInt64
+	if err := s.Model(&Order{}).Where("Amount > ?", 100).Max("Amount", &none); err != nil || none.Valid {
+		t.Fatal("expect NULL for empty set, got", none, err)
+	}
+	var amounts []int
+	if err := s.Model(&Order{}).Where("Amount < ?", 40).OrderBy("Amount DESC").Pluck("Amount", &amounts); err != nil ||
+		!reflect.DeepEqual(amounts, []int{30, 20, 10}) {
+		t.Fatal("failed to pluck, got", amounts, err)
+	}
+}
+
+func TestSession_GroupHaving(t *testing.T) {
+	s := testRecordInit(t)
+	_, _ = s.Insert(user3)
+	var stats []struct {
+		Age   int
+		Total int
+	}
+	err := s.Model(&User{}).Select("Age", "count(*) AS Total").Group("Age").Having("count(*) > ?", 1).Scan(&stats)
+	if err != nil || len(stats) != 1 || stats[0].Age != 25 || stats[0].Total != 2 {
+		t.Fatal("failed to query with group by and having, got", stats, err)
+	}
+	var users []User
+	if err := s.Group("Age").OrderBy("Age").Find(&users); err != nil || len(users) != 2 {
+		t.Fatal("failed to find with group by, got", users, err)
+	}
+}
@@ -0,0 +1,428 @@
+package session
+
+import (
+	"fmt"
+	"gamblerORM/generator"
+	"gamblerORM/schema"
+	"reflect"
+	"strings"
+)
+
+// 用于放置关联查询相关的代码，关联关系的声明方式见 schema/relationship.go
+
+// Preload 方法实现链式调用，指定 Find、First 时一并查询的关联字段，例如
+// s.Preload("Orders").Find(&users)
+// 每个关联字段只额外执行一条 WHERE 外键 IN (...) 的查询，再按外键把结果填充到对应的对象中
+// 嵌套的关联字段用 . 分隔，例如 s.Preload("Orders.Items")
+func (s *Session) Preload(names ...string) *Session {
+	s.preloads = append(s.preloads, names...)
+	return s
+}
+
+// fork 创建一个共享连接、事务、上下文和命名规则的新会话，用于在当前查询之外执行关联查询
+func (s *Session) fork() *Session {
+	return &Session{db: s.db, dialect: s.dialect, tx: s.tx, naming: s.naming, ctx: s.ctx, nowFunc: s.nowFunc}
+}
+
+// preload 查询 dest 中所有对象的关联字段，dest 是 table 对应的结构体切片
+func (s *Session) preload(dest reflect.Value, table *schema.Schema, preloads []string) error {
+	if dest.Len() == 0 {
+		return nil
+	}
+	// 同一个关联字段的嵌套字段合并到一次查询中，例如 Orders 和 Orders.Items
+	var names []string
+	nested := make(map[string][]string)
+	for _, preload := range preloads {
+		name, rest := preload, ""
+		if i := strings.Index(preload, "."); i >= 0 {
+			name, rest = preload[:i], preload[i+1:]
+		}
+		if _, ok := nested[name]; !ok {
+			names = append(names, name)
+			nested[name] = nil
+		}
+		if rest != "" {
+			nested[name] = append(nested[name], rest)
+		}
+	}
+	for _, name := range names {
+		rel := table.GetRelationship(name)
+		if rel == nil {
+			return fmt.Errorf("association.go : %s has no association %s", table.Name, name)
+		}
+		if err := s.preloadRelationship(dest, table, rel, nested[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// preloadRelationship 查询一个关联字段并填充到 dest 的每个对象中
+// 通过 schema 中字段的索引路径读写，嵌入结构体中的字段和关联字段也能正确处理
+func (s *Session) preloadRelationship(dest reflect.Value, table *schema.Schema, rel *schema.Relationship, nested []string) error {
+	if rel.Type == schema.Many2Many {
+		return s.preloadMany2Many(dest, table, rel, nested)
+	}
+	// 本结构体中用来匹配的字段和关联结构体中被匹配的字段
+	ownKey, relKey := rel.References, rel.ForeignKey
+	if rel.Type == schema.BelongsTo {
+		ownKey, relKey = rel.ForeignKey, rel.References
+	}
+	child := s.fork().Model(reflect.New(rel.FieldType).Elem().Interface())
+	relField := child.RefTable().GetField(relKey)
+	if relField == nil {
+		return fmt.Errorf("association.go : %s has no field %s", rel.FieldType.Name(), relKey)
+	}
+	ownField := table.GetField(ownKey)
+	if ownField == nil {
+		return fmt.Errorf("association.go : %s has no field %s", table.Name, ownKey)
+	}
+	keys := distinctKeys(dest, ownField)
+	if len(keys) == 0 {
+		return nil
+	}
+	children := reflect.New(reflect.SliceOf(rel.FieldType))
+	if err := child.Preload(nested...).In(relField.Column, keys).Find(children.Interface()); err != nil {
+		return err
+	}
+	// 外键的类型可能和被引用的字段不同，例如 int 和 int64，所以统一转换为字符串后匹配
+	groups := make(map[string][]reflect.Value)
+	for i := 0; i < children.Elem().Len(); i++ {
+		c := children.Elem().Index(i)
+		key := keyString(relField.ValueOf(c).Interface())
+		groups[key] = append(groups[key], c)
+	}
+	for i := 0; i < dest.Len(); i++ {
+		d := dest.Index(i)
+		setRelation(rel.SettableValueOf(d), groups[keyString(ownField.ValueOf(d).Interface())])
+	}
+	return nil
+}
+
+// preloadMany2Many 先从中间表查询出每个对象关联的主键，再一次查询出所有关联的对象
+func (s *Session) preloadMany2Many(dest reflect.Value, table *schema.Schema, rel *schema.Relationship, nested []string) error {
+	ownField := table.GetField(rel.References)
+	if ownField == nil {
+		return fmt.Errorf("association.go : %s has no field %s", table.Name, rel.References)
+	}
+	keys := distinctKeys(dest, ownField)
+	if len(keys) == 0 {
+		return nil
+	}
+	pairs, err := s.joinSession(rel).joinPairs(keys)
+	if err != nil {
+		return err
+	}
+	child := s.fork().Model(reflect.New(rel.FieldType).Elem().Interface())
+	relField := child.RefTable().GetField(rel.AssociationReferences)
+	if relField == nil {
+		return fmt.Errorf("association.go : %s has no field %s", rel.FieldType.Name(), rel.AssociationReferences)
+	}
+	var relKeys []interface{}
+	seen := make(map[string]bool)
+	for _, pair := range pairs {
+		if key := keyString(pair[1]); !seen[key] {
+			seen[key] = true
+			relKeys = append(relKeys, pair[1])
+		}
+	}
+	children := reflect.New(reflect.SliceOf(rel.FieldType))
+	if len(relKeys) > 0 {
+		if err := child.Preload(nested...).In(relField.Column, relKeys).Find(children.Interface()); err != nil {
+			return err
+		}
+	}
+	byKey := make(map[string]reflect.Value)
+	for i := 0; i < children.Elem().Len(); i++ {
+		c := children.Elem().Index(i)
+		byKey[keyString(relField.ValueOf(c).Interface())] = c
+	}
+	groups := make(map[string][]reflect.Value)
+	for _, pair := range pairs {
+		if c, ok := byKey[keyString(pair[1])]; ok {
+			groups[keyString(pair[0])] = append(groups[keyString(pair[0])], c)
+		}
+	}
+	for i := 0; i < dest.Len(); i++ {
+		d := dest.Index(i)
+		setRelation(rel.SettableValueOf(d), groups[keyString(ownField.ValueOf(d).Interface())])
+	}
+	return nil
+}
+
+// distinctKeys 收集 dest 中每个对象 field 字段去重后的值，零值表示没有关联的记录
+func distinctKeys(dest reflect.Value, field *schema.Field) []interface{} {
+	var keys []interface{}
+	seen := make(map[string]bool)
+	for i := 0; i < dest.Len(); i++ {
+		key := field.ValueOf(dest.Index(i))
+		if key.IsZero() || seen[keyString(key.Interface())] {
+			continue
+		}
+		seen[keyString(key.Interface())] = true
+		keys = append(keys, key.Interface())
+	}
+	return keys
+}
+
+// keyString 将主键、外键的值转换为字符串用于匹配，部分驱动会把字符串列读成 []byte
+func keyString(key interface{}) string {
+	if b, ok := key.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(key)
+}
+
+// setRelation 将查询到的关联对象写入关联字段，字段可以是结构体、结构体指针以及它们的切片
+func setRelation(field reflect.Value, values []reflect.Value) {
+	typ := field.Type()
+	if typ.Kind() == reflect.Slice {
+		slice := reflect.MakeSlice(typ, 0, len(values))
+		for _, v := range values {
+			slice = reflect.Append(slice, relationValue(typ.Elem(), v))
+		}
+		field.Set(slice)
+		return
+	}
+	if len(values) > 0 {
+		field.Set(relationValue(typ, values[0]))
+	}
+}
+
+// relationValue 在 typ 是指针时返回 v 的副本的指针
+func relationValue(typ reflect.Type, v reflect.Value) reflect.Value {
+	if typ.Kind() != reflect.Ptr {
+		return v
+	}
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+	return ptr
+}
+
+// joinSession 创建一个操作 many2many 中间表的会话
+func (s *Session) joinSession(rel *schema.Relationship) *Session {
+	j := s.fork()
+	j.refTable = rel.JoinTable
+	return j
+}
+
+// joinPairs 查询中间表中第一列的值属于 keys 的记录，每条记录是 {本结构体的主键, 关联结构体的主键}
+func (s *Session) joinPairs(keys []interface{}) ([][2]interface{}, error) {
+	join := s.RefTable()
+	desc, vars := s.inCondition(s.quote(join.FieldNames[0]), reflect.ValueOf(keys))
+	s.clause.Set(generator.SELECT, s.quote(join.Name), s.quoteAll(join.FieldNames))
+	s.clause.AndWhere(desc, vars...)
+	sql, vars := s.clause.Build(generator.SELECT, generator.WHERE)
+	rows, err := s.Raw(sql, vars...).QueryRows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var pairs [][2]interface{}
+	for rows.Next() {
+		var pair [2]interface{}
+		if err := rows.Scan(&pair[0], &pair[1]); err != nil {
+			return nil, err
+		}
+		for i, v := range pair {
+			if b, ok := v.([]byte); ok {
+				pair[i] = string(b)
+			}
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs, rows.Err()
+}
+
+// Association 用于操作 many2many 关联，通过 Session.Association 获取，例如
+// s.Model(&user).Association("Roles").Append(&Role{Name: "admin"})
+// 所有操作只修改中间表，会话开启了事务时在事务中执行
+type Association struct {
+	s     *Session
+	rel   *schema.Relationship
+	owner interface{} // Model 传入的对象的 References 字段的值
+	Error error       // 获取关联时发生的错误，之后的所有操作都会返回该错误
+}
+
+// Association 返回 Model 传入的对象的 many2many 关联字段 name，对象的被引用字段不能是零值
+func (s *Session) Association(name string) *Association {
+	table := s.RefTable()
+	a := &Association{s: s, rel: table.GetRelationship(name)}
+	if a.rel == nil || a.rel.Type != schema.Many2Many {
+		a.Error = fmt.Errorf("association.go : %s has no many2many association %s", table.Name, name)
+		return a
+	}
+	field := table.GetField(a.rel.References)
+	if field == nil {
+		a.Error = fmt.Errorf("association.go : %s has no field %s", table.Name, a.rel.References)
+		return a
+	}
+	key := field.ValueOf(reflect.Indirect(reflect.ValueOf(table.Model)))
+	if key.IsZero() {
+		a.Error = fmt.Errorf("association.go : %s of %s is zero", a.rel.References, table.Name)
+		return a
+	}
+	a.owner = key.Interface()
+	return a
+}
+
+// Find 查询所有关联的对象，dest 是关联结构体切片的指针
+func (a *Association) Find(dest interface{}) error {
+	if a.Error != nil {
+		return a.Error
+	}
+	pairs, err := a.s.joinSession(a.rel).joinPairs([]interface{}{a.owner})
+	if err != nil {
+		return err
+	}
+	keys := make([]interface{}, 0, len(pairs))
+	for _, pair := range pairs {
+		keys = append(keys, pair[1])
+	}
+	child := a.s.fork().Model(reflect.New(a.rel.FieldType).Elem().Interface())
+	return child.In(a.rel.AssociationReferences, keys).Find(dest)
+}
+
+// Count 返回关联的对象的数量
+func (a *Association) Count() (int64, error) {
+	if a.Error != nil {
+		return 0, a.Error
+	}
+	j := a.s.joinSession(a.rel)
+	j.clause.Set(generator.COUNT, j.quote(a.rel.JoinTable.Name))
+	j.clause.AndWhere(j.quote(a.rel.JoinTable.FieldNames[0])+" = ?", a.owner)
+	sql, vars := j.clause.Build(generator.COUNT, generator.WHERE)
+	var count int64
+	err := j.Raw(sql, vars...).QueryRow().Scan(&count)
+	return count, err
+}
+
+// Append 添加关联，values 是关联结构体的指针，被引用字段为零值的对象会先插入到关联表中，已经存在的关联会被忽略
+func (a *Association) Append(values ...interface{}) error {
+	if a.Error != nil {
+		return a.Error
+	}
+	keys, err := a.keysOf(values, true)
+	if err != nil || len(keys) == 0 {
+		return err
+	}
+	pairs, err := a.s.joinSession(a.rel).joinPairs([]interface{}{a.owner})
+	if err != nil {
+		return err
+	}
+	exists := make(map[string]bool)
+	for _, pair := range pairs {
+		exists[keyString(pair[1])] = true
+	}
+	var records []interface{}
+	for _, key := range keys {
+		if !exists[keyString(key)] {
+			exists[keyString(key)] = true
+			records = append(records, []interface{}{a.owner, key})
+		}
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	j := a.s.joinSession(a.rel)
+	j.clause.Set(generator.INSERT, j.quote(a.rel.JoinTable.Name), j.quoteAll(a.rel.JoinTable.FieldNames))
+	j.clause.Set(generator.VALUES, records...)
+	sql, vars := j.clause.Build(generator.INSERT, generator.VALUES)
+	_, err = j.Raw(sql, vars...).Exec()
+	return err
+}
+
+// Delete 删除与 values 的关联，不会删除关联的对象本身
+func (a *Association) Delete(values ...interface{}) error {
+	if a.Error != nil {
+		return a.Error
+	}
+	keys, err := a.keysOf(values, false)
+	if err != nil || len(keys) == 0 {
+		return err
+	}
+	j := a.s.joinSession(a.rel)
+	join := a.rel.JoinTable
+	desc, vars := j.inCondition(j.quote(join.FieldNames[1]), reflect.ValueOf(keys))
+	j.clause.AndWhere(j.quote(join.FieldNames[0])+" = ?", a.owner)
+	j.clause.AndWhere(desc, vars...)
+	return j.deleteJoin()
+}
+
+// Clear 删除所有关联，不会删除关联的对象本身
+func (a *Association) Clear() error {
+	if a.Error != nil {
+		return a.Error
+	}
+	j := a.s.joinSession(a.rel)
+	j.clause.AndWhere(j.quote(a.rel.JoinTable.FieldNames[0])+" = ?", a.owner)
+	return j.deleteJoin()
+}
+
+// Replace 用 values 替换所有关联，会话没有开启事务时在一个新的事务中执行
+func (a *Association) Replace(values ...interface{}) (err error) {
+	if a.Error != nil {
+		return a.Error
+	}
+	if a.s.tx != nil {
+		if err = a.Clear(); err != nil {
+			return err
+		}
+		return a.Append(values...)
+	}
+	tx := a.s.fork()
+	if err = tx.Begin(); err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.RollBack()
+			panic(p)
+		} else if err != nil {
+			_ = tx.RollBack()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+	inTx := *a
+	inTx.s = tx
+	return inTx.Replace(values...)
+}
+
+// keysOf 返回 values 的被引用字段的值，insert 为 true 时先插入被引用字段为零值的对象
+func (a *Association) keysOf(values []interface{}, insert bool) ([]interface{}, error) {
+	field := a.s.fork().Model(reflect.New(a.rel.FieldType).Elem().Interface()).RefTable().GetField(a.rel.AssociationReferences)
+	if field == nil {
+		return nil, fmt.Errorf("association.go : %s has no field %s", a.rel.FieldType.Name(), a.rel.AssociationReferences)
+	}
+	var keys []interface{}
+	for _, value := range values {
+		dest := reflect.Indirect(reflect.ValueOf(value))
+		if !dest.IsValid() || dest.Type() != a.rel.FieldType {
+			return nil, fmt.Errorf("association.go : %T is not %s", value, a.rel.FieldType.Name())
+		}
+		key := field.ValueOf(dest)
+		if key.IsZero() {
+			if !insert {
+				continue
+			}
+			if _, err := a.s.fork().Insert(value); err != nil {
+				return nil, err
+			}
+			// 不是指针时无法回填自增主键
+			if key = field.ValueOf(dest); key.IsZero() {
+				return nil, fmt.Errorf("association.go : %s of %T is zero after insert", a.rel.AssociationReferences, value)
+			}
+		}
+		keys = append(keys, key.Interface())
+	}
+	return keys, nil
+}
+
+// deleteJoin 按已经设置的 WHERE 条件删除中间表中的记录
+func (s *Session) deleteJoin() error {
+	s.clause.Set(generator.DELETE, s.quote(s.RefTable().Name))
+	sql, vars := s.clause.Build(generator.DELETE, generator.WHERE)
+	_, err := s.Raw(sql, vars...).Exec()
+	return err
+}
@@ -0,0 +1,242 @@
+package session
+
+import "testing"
+
+type Buyer struct {
+	ID     int64 `gamblerORM:"primary_key;auto_increment"`
+	Name   string
+	Card   *Card
+	Orders []Deal `gamblerORM:"foreignKey:BuyerID"`
+}
+
+type Card struct {
+	ID      int64 `gamblerORM:"primary_key;auto_increment"`
+	BuyerID int
+	Number  string
+}
+
+type Deal struct {
+	ID      int64 `gamblerORM:"primary_key;auto_increment"`
+	BuyerID int64
+	Amount  int
+	Buyer   Buyer
+}
+
+func testBuyerInit(t *testing.T) *Session {
+	t.Helper()
+	s := NewSession()
+	for _, model := range []interface{}{&Buyer{}, &Card{}, &Deal{}} {
+		_ = s.Model(model).DropTable()
+		if err := s.Model(model).CreateTable(); err != nil {
+			t.Fatal("failed to create table", err)
+		}
+	}
+	_, err1 := s.Insert(&Buyer{Name: "Tom"}, &Buyer{Name: "Sam"}, &Buyer{Name: "Jack"})
+	_, err2 := s.Insert(&Card{BuyerID: 1, Number: "6222"}, &Card{BuyerID: 2, Number: "6228"})
+	_, err3 := s.Insert(&Deal{BuyerID: 1, Amount: 10}, &Deal{BuyerID: 2, Amount: 20}, &Deal{BuyerID: 1, Amount: 30})
+	if err1 != nil || err2 != nil || err3 != nil {
+		t.Fatal("failed init test buyers")
+	}
+	return s
+}
+
+func TestSession_Preload(t *testing.T) {
+	s := testBuyerInit(t)
+	var buyers []Buyer
+	if err := s.Preload("Card", "Orders").OrderBy("ID").Find(&buyers); err != nil || len(buyers) != 3 {
+		t.Fatal("failed to find with preload, got", buyers, err)
+	}
+	if buyers[0].Card == nil || buyers[0].Card.Number != "6222" || buyers[2].Card != nil {
+		t.Fatal("failed to preload has one")
+	}
+	if len(buyers[0].Orders) != 2 || len(buyers[1].Orders) != 1 || len(buyers[2].Orders) != 0 {
+		t.Fatal("failed to preload has many, got", buyers)
+	}
+
+	var deal Deal
+	if err := s.Preload("Buyer.Orders").Where("Amount = ?", 20).First(&deal); err != nil {
+		t.Fatal("failed to preload belongs to", err)
+	}
+	if deal.Buyer.Name != "Sam" || len(deal.Buyer.Orders) != 1 || deal.Buyer.Orders[0].Amount != 20 {
+		t.Fatal("failed to preload nested association, got", deal)
+	}
+	if err := s.Preload("Unknown").Find(&buyers); err == nil {
+		t.Fatal("expect error for unknown association")
+	}
+}
+
+type Ledger struct {
+	Deals []Deal `gamblerORM:"foreignKey:BuyerID"`
+}
+
+// Vip 的主键和关联字段都在嵌入的指针中
+type Vip struct {
+	*Base
+	Name string
+	*Ledger
+}
+
+func TestSession_PreloadEmbedded(t *testing.T) {
+	s := testBuyerInit(t)
+	_ = s.Model(&Vip{}).DropTable()
+	if err := s.Model(&Vip{}).CreateTable(); err != nil {
+		t.Fatal("failed to create table", err)
+	}
+	if _, err := s.Insert(&Vip{Name: "Tom"}, &Vip{Name: "Sam"}, &Vip{Name: "Jack"}); err != nil {
+		t.Fatal("failed to insert vips", err)
+	}
+	// 查询结果中 Ledger 是 nil，填充关联字段时需要先初始化
+	var vips []Vip
+	if err := s.Preload("Deals").OrderBy("ID").Find(&vips); err != nil || len(vips) != 3 {
+		t.Fatal("failed to find with embedded preload, got", vips, err)
+	}
+	if vips[0].Ledger == nil || len(vips[0].Deals) != 2 || vips[1].Ledger == nil || len(vips[1].Deals) != 1 {
+		t.Fatal("failed to preload embedded association, got", vips)
+	}
+}
+
+type Player struct {
+	ID    int64 `gamblerORM:"primary_key;auto_increment"`
+	Name  string
+	Teams []Team `gamblerORM:"many2many:player_teams"`
+}
+
+type Team struct {
+	ID      int64 `gamblerORM:"primary_key;auto_increment"`
+	Name    string
+	Players []*Player `gamblerORM:"many2many:player_teams"`
+}
+
+func testPlayerInit(t *testing.T) (*Session, *Player) {
+	t.Helper()
+	s := NewSession()
+	_ = s.Model(&Player{}).DropJoinTables()
+	for _, model := range []interface{}{&Player{}, &Team{}} {
+		_ = s.Model(model).DropTable()
+		if err := s.Model(model).CreateTable(); err != nil {
+			t.Fatal("failed to create table", err)
+		}
+	}
+	p := &Player{Name: "Tom"}
+	if _, err := s.Insert(p, &Player{Name: "Sam"}); err != nil {
+		t.Fatal("failed init test players", err)
+	}
+	return s, p
+}
+
+func TestSession_Association(t *testing.T) {
+	s, p := testPlayerInit(t)
+	red, blue, green := &Team{Name: "red"}, &Team{Name: "blue"}, &Team{Name: "green"}
+	// 零值主键的对象会先被插入
+	if err := s.Model(p).Association("Teams").Append(red, blue); err != nil || red.ID == 0 || blue.ID == 0 {
+		t.Fatal("failed to append association", err)
+	}
+	// 已经存在的关联会被忽略
+	if err := s.Model(p).Association("Teams").Append(red); err != nil {
+		t.Fatal("failed to append existing association", err)
+	}
+	if count, err := s.Model(p).Association("Teams").Count(); err != nil || count != 2 {
+		t.Fatal("failed to count association, got", count, err)
+	}
+	if err := s.Model(p).Association("Teams").Delete(red); err != nil {
+		t.Fatal("failed to delete association", err)
+	}
+	var teams []Team
+	if err := s.Model(p).Association("Teams").Find(&teams); err != nil || len(teams) != 1 || teams[0].Name != "blue" {
+		t.Fatal("failed to find association, got", teams, err)
+	}
+	if err := s.Model(p).Association("Teams").Replace(green, red); err != nil {
+		t.Fatal("failed to replace association", err)
+	}
+	if count, _ := s.Model(p).Association("Teams").Count(); count != 2 {
+		t.Fatal("failed to replace association, got", count)
+	}
+	if err := s.Model(p).Association("Teams").Clear(); err != nil {
+		t.Fatal("failed to clear association", err)
+	}
+	if count, _ := s.Model(p).Association("Teams").Count(); count != 0 {
+		t.Fatal("failed to clear association, got", count)
+	}
+	if err := s.Model(p).Association("Name").Clear(); err == nil {
+		t.Fatal("expect error for non many2many field")
+	}
+}
+
+func TestSession_AssociationInTx(t *testing.T) {
+	s, p := testPlayerInit(t)
+	if err := s.Begin(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Model(p).Association("Teams").Replace(&Team{Name: "red"}); err != nil {
+		t.Fatal("failed to replace association in tx", err)
+	}
+	_ = s.RollBack()
+	s.tx = nil
+	var teams []Team
+	if err := s.Model(&Team{}).Find(&teams); err != nil || len(teams) != 0 {
+		t.Fatal("association should be rolled back with the tx, got", teams, err)
+	}
+}
+
+func TestSession_DropTableKeepsJoinTable(t *testing.T) {
+	s, p := testPlayerInit(t)
+	_ = s.Model(p).Association("Teams").Append(&Team{Name: "red"})
+	// 中间表由 Player 和 Team 共用，删除其中一端的表不会删除中间表
+	if err := s.Model(&Team{}).DropTable(); err != nil {
+		t.Fatal("failed to drop table", err)
+	}
+	if count, err := s.Model(p).Association("Teams").Count(); err != nil || count != 1 {
+		t.Fatal("join table should be kept after DropTable, got", count, err)
+	}
+	if err := s.Model(&Team{}).DropJoinTables(); err != nil {
+		t.Fatal("failed to drop join tables", err)
+	}
+	if s.joinSession(s.Model(p).RefTable().GetRelationship("Teams")).JudgeTableExist() {
+		t.Fatal("failed to drop join tables")
+	}
+}
+
+func TestSession_PreloadMany2Many(t *testing.T) {
+	s, p := testPlayerInit(t)
+	red, blue := &Team{Name: "red"}, &Team{Name: "blue"}
+	_ = s.Model(p).Association("Teams").Append(red, blue)
+	var sam Player
+	_ = s.Where("Name = ?", "Sam").First(&sam)
+	_ = s.Model(&sam).Association("Teams").Append(blue)
+
+	var players []Player
+	if err := s.Preload("Teams").OrderBy("ID").Find(&players); err != nil || len(players) != 2 {
+		t.Fatal("failed to find with preload, got", players, err)
+	}
+	if len(players[0].Teams) != 2 || len(players[1].Teams) != 1 || players[1].Teams[0].Name != "blue" {
+		t.Fatal("failed to preload many2many, got", players)
+	}
+	var teams []Team
+	if err := s.Preload("Players").OrderBy("ID").Find(&teams); err != nil || len(teams[1].Players) != 2 {
+		t.Fatal("failed to preload many2many from the other side, got", teams, err)
+	}
+}
+
+type Friend struct {
+	ID      int64 `gamblerORM:"primary_key;auto_increment"`
+	Name    string
+	Friends []Friend `gamblerORM:"many2many:friendships"`
+}
+
+func TestSession_Many2ManySelf(t *testing.T) {
+	s := NewSession().Model(&Friend{})
+	_ = s.DropJoinTables()
+	_ = s.DropTable()
+	if err := s.CreateTable(); err != nil {
+		t.Fatal("failed to create self-referential join table", err)
+	}
+	tom, sam, jack := &Friend{Name: "Tom"}, &Friend{Name: "Sam"}, &Friend{Name: "Jack"}
+	_, _ = s.Insert(tom)
+	if err := s.Model(tom).Association("Friends").Append(sam, jack); err != nil {
+		t.Fatal("failed to append self-referential association", err)
+	}
+	var friends []Friend
+	if err := s.Preload("Friends").Where("Name = ?", "Tom").Find(&friends); err != nil || len(friends) != 1 || len(friends[0].Friends) != 2 {
+		t.Fatal("failed to preload self-referential association, got", friends, err)
+	}
+}
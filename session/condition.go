@@ -0,0 +1,153 @@
+package session
+
+import (
+	"fmt"
+	"gamblerORM/log"
+	"gamblerORM/schema"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// 用于放置构造 WHERE 条件相关的代码，多次调用的条件之间默认用 AND 连接，例如
+// s.Where("Age > ?", 18).Where(map[string]interface{}{"Name": "Tom"}).OrWhere("Age IS NULL")
+// 生成 WHERE (Age > ?) AND (Name = ?) OR (Age IS NULL)
+// OrWhere 之后再调用 Where 时，之前的条件会作为一个整体，例如 Where(a).OrWhere(b).Where(c) 生成 WHERE ((a) OR (b)) AND (c)
+
+// Where 方法实现链式调用，关键是返回 *Session
+// query 可以是带 ? 占位符的字符串、map[string]interface{} 或者结构体，map 和结构体的各个字段之间用 AND 连接
+func (s *Session) Where(query interface{}, args ...interface{}) *Session {
+	// 没有非零值字段的结构体和空 map 不产生条件
+	if desc, vars := s.buildCondition(query, args...); desc != "" {
+		s.clause.AndWhere(desc, vars...)
+	}
+	return s
+}
+
+// OrWhere 追加一个和之前的条件用 OR 连接的条件，参数和 Where 相同
+func (s *Session) OrWhere(query interface{}, args ...interface{}) *Session {
+	if desc, vars := s.buildCondition(query, args...); desc != "" {
+		s.clause.OrWhere(desc, vars...)
+	}
+	return s
+}
+
+// Not 追加一个取反的条件，参数和 Where 相同
+func (s *Session) Not(query interface{}, args ...interface{}) *Session {
+	if desc, vars := s.buildCondition(query, args...); desc != "" {
+		s.clause.AndWhere("NOT ("+desc+")", vars...)
+	}
+	return s
+}
+
+// In 追加 col IN (?, ?, ...) 条件，占位符的数量和 values 切片的长度一致
+func (s *Session) In(col string, values interface{}) *Session {
+	desc, vars := s.inCondition(s.qualifiedColumnOf(col), reflect.ValueOf(values))
+	s.clause.AndWhere(desc, vars...)
+	return s
+}
+
+// Between 追加 col BETWEEN ? AND ? 条件
+func (s *Session) Between(col string, min, max interface{}) *Session {
+	s.clause.AndWhere(s.qualifiedColumnOf(col)+" BETWEEN ? AND ?", min, max)
+	return s
+}
+
+// IsNull 追加 col IS NULL 条件
+func (s *Session) IsNull(col string) *Session {
+	s.clause.AndWhere(s.qualifiedColumnOf(col) + " IS NULL")
+	return s
+}
+
+// Like 追加 col LIKE ? 条件，通配符需要调用方写在 pattern 中，例如 "Tom%"
+func (s *Session) Like(col string, pattern string) *Session {
+	s.clause.AndWhere(s.qualifiedColumnOf(col)+" LIKE ?", pattern)
+	return s
+}
+
+// buildCondition 将 Where 的参数转换为条件字符串和对应的参数
+// 不支持的类型不产生条件，错误记录在 Session 上，由下一条执行的语句返回
+func (s *Session) buildCondition(query interface{}, args ...interface{}) (string, []interface{}) {
+	switch q := query.(type) {
+	case string:
+		return q, args
+	case map[string]interface{}:
+		return s.mapCondition(q)
+	}
+	value := reflect.Indirect(reflect.ValueOf(query))
+	if value.Kind() == reflect.Struct {
+		return s.structCondition(query)
+	}
+	s.err = fmt.Errorf("unsupported condition type %T", query)
+	log.Error(s.err)
+	return "", nil
+}
+
+// mapCondition map 的 key 是字段名或者列名，为了生成的 SQL 稳定，按 key 排序后再拼接
+// 值为 nil 时生成 IS NULL，值为切片时生成 IN
+func (s *Session) mapCondition(m map[string]interface{}) (string, []interface{}) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var conditions []string
+	var vars []interface{}
+	for _, k := range keys {
+		col := s.qualifiedColumnOf(k)
+		v := reflect.ValueOf(m[k])
+		switch {
+		case m[k] == nil:
+			conditions = append(conditions, col+" IS NULL")
+		case (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() != reflect.Uint8:
+			desc, inVars := s.inCondition(col, v)
+			conditions = append(conditions, desc)
+			vars = append(vars, inVars...)
+		default:
+			conditions = append(conditions, col+" = ?")
+			vars = append(vars, m[k])
+		}
+	}
+	return strings.Join(conditions, " AND "), vars
+}
+
+// structCondition 结构体中非零值的字段作为条件，按字段的顺序拼接
+func (s *Session) structCondition(query interface{}) (string, []interface{}) {
+	table := s.refTable
+	if table == nil || reflect.Indirect(reflect.ValueOf(table.Model)).Type() != reflect.Indirect(reflect.ValueOf(query)).Type() {
+		var err error
+		if table, err = schema.ParseWithNaming(query, s.dialect, s.naming); err != nil {
+			log.Error(err)
+		}
+	}
+	dest := reflect.Indirect(reflect.ValueOf(query))
+	var conditions []string
+	var vars []interface{}
+	for _, field := range table.Fields {
+		if field.ValueOf(dest).IsZero() {
+			continue
+		}
+		// 和 Model 的类型相同时联表查询要加上表名，其他类型的结构体无法确定所在的表
+		col := s.quote(field.Column)
+		if table == s.refTable {
+			col = s.qualifiedColumn(field.Column)
+		}
+		conditions = append(conditions, col+" = ?")
+		vars = append(vars, field.DBValueOf(dest))
+	}
+	return strings.Join(conditions, " AND "), vars
+}
+
+// inCondition 生成 col IN (?, ?, ...)，空切片时生成恒为假的条件
+func (s *Session) inCondition(col string, values reflect.Value) (string, []interface{}) {
+	if values.Len() == 0 {
+		return "1 = 0", nil
+	}
+	placeholders := make([]string, values.Len())
+	vars := make([]interface{}, values.Len())
+	for i := 0; i < values.Len(); i++ {
+		placeholders[i] = "?"
+		vars[i] = values.Index(i).Interface()
+	}
+	return fmt.Sprintf("%s IN (%s)", col, strings.Join(placeholders, ", ")), vars
+}
@@ -0,0 +1,80 @@
+package session
+
+import (
+	"gamblerORM/generator"
+	"reflect"
+	"testing"
+)
+
+func testConditionSQL(t *testing.T, s *Session) (string, []interface{}) {
+	t.Helper()
+	s.clause.Set(generator.SELECT, s.quote(s.RefTable().Name), []string{"*"})
+	return s.clause.Build(generator.SELECT, generator.WHERE)
+}
+
+func TestSession_WhereChain(t *testing.T) {
+	s := NewSession().Model(&User{})
+	s.Where("Name = ?", "Tom").Where("Age > ?", 18).OrWhere("Age IS NULL")
+	sql, vars := testConditionSQL(t, s)
+	if sql != "SELECT * FROM `User` WHERE (Name = ?) AND (Age > ?) OR (Age IS NULL)" {
+		t.Fatal("failed to chain where, got", sql)
+	}
+	if !reflect.DeepEqual(vars, []interface{}{"Tom", 18}) {
+		t.Fatal("failed to chain where vars, got", vars)
+	}
+}
+
+func TestSession_WhereHelpers(t *testing.T) {
+	s := NewSession().Model(&User{})
+	s.In("Name", []string{"Tom", "Sam"}).Between("Age", 18, 30).Not("Name = ?", "Jack").Like("Name", "T%").IsNull("Age")
+	sql, vars := testConditionSQL(t, s)
+	expect := "SELECT * FROM `User` WHERE (`Name` IN (?, ?)) AND (`Age` BETWEEN ? AND ?) AND (NOT (Name = ?)) AND (`Name` LIKE ?) AND (`Age` IS NULL)"
+	if sql != expect {
+		t.Fatal("failed to build condition helpers, got", sql)
+	}
+	if !reflect.DeepEqual(vars, []interface{}{"Tom", "Sam", 18, 30, "Jack", "T%"}) {
+		t.Fatal("failed to build condition vars, got", vars)
+	}
+}
+
+func TestSession_WhereMapAndStruct(t *testing.T) {
+	s := NewSession().Model(&User{})
+	s.Where(map[string]interface{}{"Name": []string{"Tom"}, "Age": 18}).Where(&User{Name: "Tom"})
+	sql, vars := testConditionSQL(t, s)
+	if sql != "SELECT * FROM `User` WHERE (`Age` = ? AND `Name` IN (?)) AND (`Name` = ?)" {
+		t.Fatal("failed to build map and struct condition, got", sql)
+	}
+	if !reflect.DeepEqual(vars, []interface{}{18, "Tom", "Tom"}) {
+		t.Fatal("failed to build map and struct vars, got", vars)
+	}
+}
+
+func TestSession_WhereFind(t *testing.T) {
+	s := testRecordInit(t)
+	_, _ = s.Insert(user3)
+	var users []User
+	// 之前的实现中第二个 Where 会覆盖第一个
+	if err := s.Where("Age = ?", 25).Where("Name = ?", "Sam").Find(&users); err != nil || len(users) != 1 {
+		t.Fatal("failed to query with chained where, got", users)
+	}
+	users = nil
+	if err := s.In("Name", []string{"Tom", "Jack"}).Find(&users); err != nil || len(users) != 2 {
+		t.Fatal("failed to query with in, got", users)
+	}
+}
+
+func TestSession_WhereUnsupportedType(t *testing.T) {
+	s := testRecordInit(t)
+	var users []User
+	// 不支持的条件类型不再 panic，错误由下一条语句返回
+	if err := s.Where(5).Find(&users); err == nil {
+		t.Fatal("expect error for unsupported condition type")
+	}
+	if _, err := s.Where(5).Count(); err == nil {
+		t.Fatal("expect count to return unsupported condition error")
+	}
+	// 错误只对下一条语句生效
+	if err := s.Find(&users); err != nil || len(users) != 2 {
+		t.Fatal("failed to query after unsupported condition, got", users, err)
+	}
+}
@@ -0,0 +1,47 @@
+package session
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Pagination 是分页查询的元信息
+type Pagination struct {
+	Page  int   // 当前页码，从 1 开始
+	Size  int   // 每页的记录数
+	Total int64 // 符合条件的记录总数
+	Pages int   // 总页数
+}
+
+// Paginate 分页查询，将第 page 页的记录写入 dest，并返回记录总数和总页数，例如
+// p, err := s.Where("Age > ?", 18).OrderBy("Age").Paginate(2, 10, &users)
+// 链式调用设置的条件同时作用于计数和查询，调用了 Group 时总数是分组的数量
+func (s *Session) Paginate(page, size int, dest interface{}) (*Pagination, error) {
+	if size < 1 {
+		s.Clear()
+		return nil, fmt.Errorf("invalid page size %d", size)
+	}
+	if page < 1 {
+		page = 1
+	}
+	destType := reflect.Indirect(reflect.ValueOf(dest)).Type().Elem()
+	s.Model(reflect.New(destType).Elem().Interface())
+	// Count 执行之后会清空子句，所以要先保存下链式调用设置的条件
+	clause := s.clause.Clone()
+	selects, omits, preloads, unscoped := s.selects, s.omits, s.preloads, s.unscoped
+	total, err := s.Count()
+	if err != nil {
+		return nil, err
+	}
+	s.clause = clause
+	s.selects, s.omits, s.preloads, s.unscoped = selects, omits, preloads, unscoped
+	if err := s.Limit(size).Offset((page - 1) * size).Find(dest); err != nil {
+		return nil, err
+	}
+	return &Pagination{
+		Page:  page,
+		Size:  size,
+		Total: total,
+		Pages: int((total + int64(size) - 1) / int64(size)),
+	}, nil
+}
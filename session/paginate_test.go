@@ -0,0 +1,75 @@
+package session
+
+import (
+	"gamblerORM/dialect"
+	"testing"
+)
+
+func TestSession_Paginate(t *testing.T) {
+	s := testOrderInit(t, 7)
+	var orders []Order
+	p, err := s.Where("Amount > ?", 10).OrderBy("ID").Paginate(2, 4, &orders)
+	// Amount 为 20 ~ 70 的 6 条记录，每页 4 条，第 2 页是 ID 为 6、7 的记录
+	if err != nil || p.Total != 6 || p.Pages != 2 || p.Page != 2 {
+		t.Fatal("failed to count pages, got", p, err)
+	}
+	if len(orders) != 2 || orders[0].ID != 6 || orders[1].ID != 7 {
+		t.Fatal("failed to query page, got", orders)
+	}
+}
+
+func TestSession_PaginatePreload(t *testing.T) {
+	s := testBuyerInit(t)
+	var buyers []Buyer
+	// Count 之后 Preload 仍然对查询生效
+	p, err := s.Preload("Orders").OrderBy("ID").Paginate(1, 2, &buyers)
+	if err != nil || p.Total != 3 || len(buyers) != 2 {
+		t.Fatal("failed to paginate with preload, got", p, buyers, err)
+	}
+	if len(buyers[0].Orders) != 2 || len(buyers[1].Orders) != 1 {
+		t.Fatal("failed to preload in paginate, got", buyers)
+	}
+}
+
+func TestSession_PaginateGroup(t *testing.T) {
+	s := testOrderInit(t, 7)
+	var orders []Order
+	// Amount 为 20 ~ 70 的 6 条记录按是否大于 30 分为 2 组，HAVING 之后只剩 1 组，总数是分组的数量
+	p, err := s.Where("Amount > ?", 10).Group("Amount > 30").Having("COUNT(*) > ?", 2).Paginate(1, 10, &orders)
+	if err != nil || p.Total != 1 || p.Pages != 1 {
+		t.Fatal("failed to count groups, got", p, err)
+	}
+	if len(orders) != 1 {
+		t.Fatal("failed to query grouped page, got", orders)
+	}
+}
+
+func TestSession_Offset(t *testing.T) {
+	s := testOrderInit(t, 5)
+	var orders []Order
+	if err := s.OrderBy("ID").Limit(2).Offset(3).Find(&orders); err != nil || len(orders) != 2 || orders[0].ID != 4 {
+		t.Fatal("failed to query with offset, got", orders)
+	}
+}
+
+func TestSession_OffsetWithoutLimit(t *testing.T) {
+	s := testOrderInit(t, 5)
+	var orders []Order
+	// sqlite3 不支持单独使用 OFFSET，会补上 LIMIT -1
+	if err := s.OrderBy("ID").Offset(3).Find(&orders); err != nil || len(orders) != 2 || orders[0].ID != 4 {
+		t.Fatal("failed to query with offset only, got", orders, err)
+	}
+	// 不需要真实的数据库服务，只验证生成的 SQL
+	cases := map[string]string{
+		"mysql":    "SELECT `ID`,`Amount` FROM `Order` LIMIT 18446744073709551615 OFFSET ? ",
+		"postgres": `SELECT "ID","Amount" FROM "Order" OFFSET ? `,
+	}
+	for name, expect := range cases {
+		d, _ := dialect.GetDialect(name)
+		s := New(nil, d).Model(&Order{}).Offset(3)
+		s.buildScanSQL()
+		if sql := s.sql.String(); sql != expect {
+			t.Fatal("failed to build offset without limit for", name, "got", sql)
+		}
+	}
+}
@@ -1,88 +1,197 @@
 package session
 
 import (
+	"database/sql"
 	"errors"
+	"fmt"
+	"gamblerORM/dialect"
 	"gamblerORM/generator"
+	"gamblerORM/schema"
 	"reflect"
+	"strings"
+)
+
+var (
+	ErrRecordNotFound   = errors.New("NOT FOUND")
+	ErrNoPrimaryKey     = errors.New("model has no primary key")
+	ErrNoColumnSelected = errors.New("no column selected")
+	ErrMissingCondition = errors.New("missing primary key or WHERE condition")
+	ErrStaleObject      = errors.New("stale object: record has been modified or deleted")
 )
 
 // Insert 实现 insert 功能
 // 1）多次调用 clause.Set() 构造好每一个子句。
 // 2）调用一次 clause.Build() 按照传入的顺序构造出最终的 SQL 语句。
+// 如果表有自增主键，自增列为零值的记录由数据库生成主键，并回填到传入的对象中（需要传入指针）
 func (s *Session) Insert(values ...interface{}) (int64, error) {
-	recordValues := make([]interface{}, 0)
 	//例如要执行这样的插入语句
 	//INSERT INTO table_name(col1, col2, col3, ...) VALUES
 	//(A1, A2, A3, ...),
 	//(B1, B2, B3, ...),
 	//...
+	var autoRows, otherRows []interface{}
 	for _, value := range values {
-		// 调用钩子 BeforeInsert，但是没有进行测试
-		s.CallMethod(BeforeInsert, value)
 		table := s.Model(value).RefTable()
-		s.clause.Set(generator.INSERT, table.Name, table.FieldNames)
+		// 调用钩子 BeforeInsert，钩子可能会修改主键，所以之后再判断自增列是否为零值
+		s.CallMethod(BeforeInsert, value)
+		value = s.setInsertFields(value)
+		if auto := table.AutoIncrementField; auto != nil &&
+			auto.ValueOf(reflect.Indirect(reflect.ValueOf(value))).IsZero() {
+			autoRows = append(autoRows, value)
+		} else {
+			otherRows = append(otherRows, value)
+		}
+	}
+	// 同一条 INSERT 语句中每一行的列必须相同，所以自增列为零值和非零值的记录分开插入
+	var affected int64
+	if len(otherRows) > 0 {
+		n, err := s.insertRows(otherRows, false)
+		if err != nil {
+			return 0, err
+		}
+		affected += n
+	}
+	if len(autoRows) > 0 {
+		n, err := s.insertRows(autoRows, true)
+		if err != nil {
+			return affected, err
+		}
+		affected += n
+	}
+	// 调用钩子 AfterInsert
+	s.CallMethod(AfterInsert, nil)
+	return affected, nil
+}
+
+// insertRows 用一条 INSERT 语句插入多条记录，omitAuto 为 true 时不写入自增列，并将数据库生成的主键回填到对象中
+func (s *Session) insertRows(values []interface{}, omitAuto bool) (int64, error) {
+	table := s.RefTable()
+	auto := table.AutoIncrementField
+	var columns []string
+	for _, field := range table.Fields {
+		if !omitAuto || field != auto {
+			columns = append(columns, field.Column)
+		}
+	}
+	recordValues := make([]interface{}, 0, len(values))
+	for _, value := range values {
 		// 得到和列名对应的一行数据，如有3列，则对应 {A1, B1, C1}
-		recordValues = append(recordValues, table.RecordValues(value))
+		record := table.RecordValues(value)
+		if omitAuto {
+			for i, field := range table.Fields {
+				if field == auto {
+					record = append(record[:i:i], record[i+1:]...)
+					break
+				}
+			}
+		}
+		recordValues = append(recordValues, record)
 	}
+	s.clause.Set(generator.INSERT, s.quote(table.Name), s.quoteAll(columns))
 	// 拼接所有的参数得到values子句, recordValues 不只是一条，需要加 ...
 	s.clause.Set(generator.VALUES, recordValues...)
-	// 按顺序调用 select 子句 和 values 子句
+	if !omitAuto {
+		// 按顺序调用 insert 子句 和 values 子句
+		sql, vars := s.clause.Build(generator.INSERT, generator.VALUES)
+		result, err := s.Raw(sql, vars...).Exec()
+		if err != nil {
+			return 0, err
+		}
+		return result.RowsAffected()
+	}
+	// 不支持 LastInsertId 的数据库通过 RETURNING 子句拿到每一行生成的主键
+	if s.dialect.InsertID() == dialect.RETURNING {
+		s.clause.Set(generator.RETURNING, s.quote(auto.Column))
+		sql, vars := s.clause.Build(generator.INSERT, generator.VALUES, generator.RETURNING)
+		rows, err := s.Raw(sql, vars...).QueryRows()
+		if err != nil {
+			return 0, err
+		}
+		defer rows.Close()
+		var affected int64
+		for ; rows.Next(); affected++ {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				return affected, err
+			}
+			if int(affected) < len(values) {
+				setAutoIncrement(values[affected], auto, id)
+			}
+		}
+		return affected, rows.Err()
+	}
 	sql, vars := s.clause.Build(generator.INSERT, generator.VALUES)
-	// 执行查询
 	result, err := s.Raw(sql, vars...).Exec()
 	if err != nil {
 		return 0, err
 	}
-	// 调用钩子 AfterInsert
-	s.CallMethod(AfterInsert, nil)
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	// 多行插入时 LastInsertId 只返回一个值，sqlite3 返回最后一行的主键，mysql 返回第一行的主键，其余行的主键是连续的
+	if s.dialect.InsertID() == dialect.LASTID {
+		id -= int64(len(values) - 1)
+	}
+	for i, value := range values {
+		setAutoIncrement(value, auto, id+int64(i))
+	}
 	return result.RowsAffected()
 }
 
+// setAutoIncrement 将数据库生成的主键写回对象的自增字段 auto，对象不是指针时无法回填
+func setAutoIncrement(value interface{}, auto *schema.Field, id int64) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Ptr {
+		return
+	}
+	field := auto.SettableValueOf(v.Elem())
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		field.SetInt(id)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		field.SetUint(uint64(id))
+	}
+}
+
 // Find 实现 Find 功能
 // Find 功能的难点和 Insert 恰好反了过来。Insert 需要将已经存在的对象的每一个字段的值平铺开来，而 Find 则是需要根据平铺开的字段的值构造出对象
+// 通过 Select、Omit 可以只查询部分列，没有查询的字段保持零值，例如 s.Omit("Avatar").Find(&users)
 func (s *Session) Find(values interface{}) error {
-	// 调用钩子 BeforeQuery
-	s.CallMethod(BeforeQuery, nil)
 	// 拿到多个对象的每个字段的值
 	destSlice := reflect.Indirect(reflect.ValueOf(values))
 	// 获取切片的单个元素的类型 destType
 	destType := destSlice.Type().Elem()
 	// reflect.New() 方法创建一个 destType 的实例，作为 Model() 的入参，映射出表结构 RefTable()
-	table := s.Model(reflect.New(destType).Elem().Interface()).RefTable()
-
-	//开始构建子句
-	s.clause.Set(generator.SELECT, table.Name, table.FieldNames)
-	sql, vars := s.clause.Build(generator.SELECT, generator.WHERE, generator.ORDERBY, generator.LIMIT)
+	s.Model(reflect.New(destType).Elem().Interface())
+	// 执行查询后会清空 Preload，所以要提前记录下来
+	table, preloads := s.RefTable(), s.preloads
 	// 执行查找
-	rows, err := s.Raw(sql, vars...).QueryRows()
+	rows, err := s.Rows()
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
 	// 数据库返回的多条记录要使用 Next()来遍历
 	for rows.Next() {
-		// 遍历每一行记录，利用反射创建 destType 的实例 dest，将 dest 的所有字段平铺开，构造切片 values
-		dest := reflect.New(destType).Elem()
-		var values []interface{}
-		for _, name := range table.FieldNames {
-			values = append(values, dest.FieldByName(name).Addr().Interface())
-		}
-		// 调用 rows.Scan() 将该行记录每一列的值依次赋值给 values 中的每一个字段
-		if err := rows.Scan(values...); err != nil {
+		// 遍历每一行记录，利用反射创建 destType 的实例 dest，由 rows.Scan() 将该行记录赋值给 dest 的字段
+		dest := reflect.New(destType)
+		if err := rows.Scan(dest.Interface()); err != nil {
 			return err
 		}
-		// Addr() 方法询问 reflect.Value 变量是否可寻址
-		// 调用钩子 AfterQuery
-		s.CallMethod(AfterQuery, dest.Addr().Interface())
 		// 将 dest 添加到切片 destSlice 中。循环直到所有的记录都添加到切片 destSlice 中
-		destSlice.Set(reflect.Append(destSlice, dest))
+		destSlice.Set(reflect.Append(destSlice, dest.Elem()))
+	}
+	// 遍历过程中上下文被取消等错误需要通过 rows.Err() 获取
+	if err := rows.Err(); err != nil {
+		return err
 	}
-	return rows.Close()
+	_ = rows.Close()
+	return s.preload(destSlice, table, preloads)
 }
 
 // Update 功能实现：kv是多个不定长度的参数
 func (s *Session) Update(kv ...interface{}) (int64, error) {
-	// 调用钩子 BeforeUpdate
-	s.CallMethod(BeforeUpdate, nil)
 	// 类相转化
 	m, ok := kv[0].(map[string]interface{})
 	if !ok {
@@ -92,22 +201,276 @@ func (s *Session) Update(kv ...interface{}) (int64, error) {
 			m[kv[i].(string)] = kv[i+1]
 		}
 	}
+	return s.updateMap(m, nil)
+}
+
+// updateMap 根据 map 更新记录，map 的 key 是字段名或者列名，model 不为 nil 时对它使用乐观锁
+func (s *Session) updateMap(m map[string]interface{}, model interface{}) (affected int64, err error) {
+	// 调用钩子 BeforeUpdate
+	s.CallMethod(BeforeUpdate, nil)
+	// 列名需要加上引号
+	quoted := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		quoted[s.quote(s.columnOf(k))] = v
+	}
+	s.setUpdateTime(nil, quoted)
+	if model != nil {
+		affected, err = s.lockedUpdate(quoted, model)
+	} else {
+		affected, err = s.execUpdate(quoted)
+	}
+	if err != nil {
+		return 0, err
+	}
+	// 调用钩子 AfterUpdate
+	s.CallMethod(AfterUpdate, nil)
+	return affected, nil
+}
+
+// Select 指定下一条语句要操作的字段，参数可以是字段名或者列名
+// 用于 Updates 时，选中的字段即使是零值也会被更新
+func (s *Session) Select(fields ...string) *Session {
+	s.selects = append(s.selects, fields...)
+	return s
+}
+
+// Omit 指定下一条语句要排除的字段，参数可以是字段名或者列名
+func (s *Session) Omit(fields ...string) *Session {
+	s.omits = append(s.omits, fields...)
+	return s
+}
+
+// fieldSelected 判断字段是否被 Select 选中且没有被 Omit 排除，没有调用 Select 时所有字段都视为选中
+func (s *Session) fieldSelected(field *schema.Field) bool {
+	contains := func(names []string) bool {
+		for _, name := range names {
+			if name == field.Name || name == field.Column {
+				return true
+			}
+		}
+		return false
+	}
+	if contains(s.omits) {
+		return false
+	}
+	return len(s.selects) == 0 || contains(s.selects)
+}
+
+// selectedFields 返回被 Select 选中且没有被 Omit 排除的字段，顺序和表中列的顺序一致
+func (s *Session) selectedFields() []*schema.Field {
+	var fields []*schema.Field
+	for _, field := range s.RefTable().Fields {
+		if s.fieldSelected(field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// Updates 根据结构体更新记录，例如 s.Model(&user).Updates(User{Name: "Tom"})
+// 1）默认只更新非零值的字段，通过 Select 选中的字段即使是零值也会更新，Omit 排除的字段不会更新
+// 2）主键不会被更新，value 或者 Model 传入的对象的主键不是零值时，会作为 WHERE 条件，主键都是零值且没有调用 Where 时返回 ErrMissingCondition
+// 3）value 是 map 时和 Update 相同，所有的键值对都会更新，Model 传入的对象的主键不是零值时同样作为 WHERE 条件，map 中的版本号会被忽略
+// 4）表有版本号时，value 或者 Model 传入的对象的版本号不是零值时使用乐观锁，没有更新到记录时返回 ErrStaleObject
+func (s *Session) Updates(value interface{}) (int64, error) {
+	if m, ok := value.(map[string]interface{}); ok {
+		if !s.scopePrimary(s.RefTable().Model) {
+			s.Clear()
+			return 0, ErrMissingCondition
+		}
+		return s.updateMap(m, s.RefTable().Model)
+	}
+	destValue := reflect.Indirect(reflect.ValueOf(value))
+	// Model 传入的是 &user 而 value 是 User{} 时，不能用 value 覆盖 Model 传入的对象
+	if s.refTable == nil || reflect.Indirect(reflect.ValueOf(s.refTable.Model)).Type() != destValue.Type() {
+		s.Model(value)
+	}
+	table := s.RefTable()
+	// 调用钩子 BeforeUpdate，钩子可能会修改对象，所以要在钩子之后再取值
+	s.CallMethod(BeforeUpdate, value)
+	m := make(map[string]interface{})
+	for _, field := range table.Fields {
+		// 版本号由乐观锁维护，不能直接更新
+		if field.PrimaryKey || field.Version || !s.fieldSelected(field) {
+			continue
+		}
+		if len(s.selects) == 0 && field.ValueOf(destValue).IsZero() {
+			continue
+		}
+		m[s.quote(field.Column)] = field.DBValueOf(destValue)
+	}
+	if len(m) == 0 {
+		s.Clear()
+		return 0, nil
+	}
+	// 优先使用 value 的主键，其次使用 Model 传入的对象的主键
+	if !s.scopePrimary(value, table.Model) {
+		s.Clear()
+		return 0, ErrMissingCondition
+	}
+	// value 不是指针时，更新时间写回 Model 传入的对象
+	target := value
+	if reflect.ValueOf(value).Kind() != reflect.Ptr {
+		target = table.Model
+	}
+	s.setUpdateTime(target, m)
+	// 和主键一样，优先使用 value 的版本号
+	affected, err := s.lockedUpdate(m, value, table.Model)
+	if err != nil {
+		return 0, err
+	}
+	// 调用钩子 AfterUpdate
+	s.CallMethod(AfterUpdate, value)
+	return affected, nil
+}
+
+// scopePrimary 取 values 中第一个主键不是零值的对象，追加主键条件，主键条件不能被之前的 OrWhere 绕过
+// 返回 false 表示既没有主键也没有 WHERE 条件，此时会更新整张表，通常是忘记了设置条件
+func (s *Session) scopePrimary(values ...interface{}) bool {
+	if len(s.RefTable().PrimaryFields) > 0 {
+		for _, value := range values {
+			if keys, zero := s.primaryValues(value); !zero {
+				desc, vars, _ := s.primaryCondition(keys)
+				s.clause.WrapWhere(desc, vars...)
+				return true
+			}
+		}
+	}
+	return s.clause.Has(generator.WHERE)
+}
+
+// execUpdate 执行 UPDATE 语句，m 的 key 是已经加上引号的列名
+func (s *Session) execUpdate(m map[string]interface{}) (int64, error) {
 	// 构造子句, UPDATE 语句，表名和参数
-	s.clause.Set(generator.UPDATE, s.RefTable().Name, m)
+	s.clause.Set(generator.UPDATE, s.quote(s.RefTable().Name), m)
 	// 合成完成的sql语句
 	sql, vars := s.clause.Build(generator.UPDATE, generator.WHERE)
 	result, err := s.Raw(sql, vars...).Exec()
 	if err != nil {
 		return 0, err
 	}
-	// 调用钩子 AfterUpdate
-	s.CallMethod(AfterUpdate, nil)
 	return result.RowsAffected()
 }
 
-// Delete 删除功能实现
+// setInsertFields 将 value 中为零值的自动时间戳字段设置为当前时间，为零值的版本号设置为 1，返回设置后的对象
+// value 不是指针时无法修改，会设置到它的副本中
+func (s *Session) setInsertFields(value interface{}) interface{} {
+	table := s.RefTable()
+	v := reflect.ValueOf(value)
+	dest := reflect.Indirect(v)
+	copied := false
+	for _, field := range table.Fields {
+		unit := field.AutoCreateTime
+		if unit == schema.NoAutoTime {
+			unit = field.AutoUpdateTime
+		}
+		if (unit == schema.NoAutoTime && !field.Version) || !field.ValueOf(dest).IsZero() {
+			continue
+		}
+		if !copied && v.Kind() != reflect.Ptr {
+			v = reflect.New(dest.Type())
+			v.Elem().Set(dest)
+			dest, value = v.Elem(), v.Interface()
+		}
+		copied = true
+		if field.Version {
+			setVersion(field, dest, 1)
+		} else {
+			field.SettableValueOf(dest).Set(field.TimeValue(unit, s.now()))
+		}
+	}
+	return value
+}
+
+// setUpdateTime 将被选中的自动更新时间字段设置为当前时间，m 的 key 是已经加上引号的列名
+// value 是结构体的指针时会同时修改 value，m 中已经有的列不会被覆盖，除非来自 value 本身
+func (s *Session) setUpdateTime(value interface{}, m map[string]interface{}) {
+	v := reflect.ValueOf(value)
+	for _, field := range s.RefTable().Fields {
+		if field.AutoUpdateTime == schema.NoAutoTime || !s.fieldSelected(field) {
+			continue
+		}
+		column := s.quote(field.Column)
+		if _, ok := m[column]; ok && value == nil {
+			continue
+		}
+		t := field.TimeValue(field.AutoUpdateTime, s.now())
+		m[column] = t.Interface()
+		if v.Kind() == reflect.Ptr && !v.IsNil() {
+			field.SettableValueOf(v.Elem()).Set(t)
+		}
+	}
+}
+
+// lockVersion 实现乐观锁：取 values 中第一个版本号不是零值的对象，追加版本号和它相同的条件，并将加一后的版本号写入 m
+// 返回该对象和新的版本号，更新成功后通过 setVersion 写回对象，ok 为 false 表示表没有版本号或者对象的版本号都是零值
+// 不使用乐观锁时 m 中不会包含版本号，避免覆盖数据库中的版本号
+func (s *Session) lockVersion(m map[string]interface{}, values ...interface{}) (dest reflect.Value, version int64, ok bool) {
+	field := s.RefTable().VersionField
+	if field == nil {
+		return reflect.Value{}, 0, false
+	}
+	delete(m, s.quote(field.Column))
+	for _, value := range values {
+		dest = reflect.Indirect(reflect.ValueOf(value))
+		if !dest.IsValid() {
+			continue
+		}
+		v := field.ValueOf(dest)
+		if v.IsZero() {
+			continue
+		}
+		if v.CanInt() {
+			version = v.Int()
+		} else {
+			version = int64(v.Uint())
+		}
+		// 版本号条件不能被之前的 OrWhere 绕过，否则满足其他条件的记录会在不检查版本号的情况下被覆盖
+		s.clause.WrapWhere(s.quote(field.Column)+" = ?", version)
+		m[s.quote(field.Column)] = version + 1
+		return dest, version + 1, true
+	}
+	return reflect.Value{}, 0, false
+}
+
+// lockedUpdate 使用 values 的版本号执行 UPDATE 语句，使用乐观锁却没有更新到记录时返回 ErrStaleObject
+// 更新成功后将新的版本号写回对象
+func (s *Session) lockedUpdate(m map[string]interface{}, values ...interface{}) (int64, error) {
+	field := s.RefTable().VersionField
+	dest, version, locked := s.lockVersion(m, values...)
+	affected, err := s.execUpdate(m)
+	if err != nil {
+		return 0, err
+	}
+	if locked {
+		if affected == 0 {
+			return 0, ErrStaleObject
+		}
+		setVersion(field, dest, version)
+	}
+	return affected, nil
+}
+
+// setVersion 将版本号写回 dest 的版本号字段，dest 不可寻址时忽略
+func setVersion(field *schema.Field, dest reflect.Value, version int64) {
+	if dest.CanAddr() {
+		field.SettableValueOf(dest).Set(reflect.ValueOf(version).Convert(field.FieldType))
+	}
+}
+
+// Delete 删除功能实现，表有软删除列时只设置删除时间，通过 Unscoped().Delete() 才会真正删除记录
 func (s *Session) Delete() (int64, error) {
-	s.clause.Set(generator.DELETE, s.RefTable().Name)
+	return s.execDelete()
+}
+
+// execDelete 按已经设置的 WHERE 条件删除记录
+// 表有软删除列且没有调用 Unscoped 时改为执行 UPDATE，将没有被删除的记录的 DeletedAt 设置为当前时间
+func (s *Session) execDelete() (int64, error) {
+	if field := s.RefTable().DeletedAtField; field != nil && !s.unscoped {
+		s.softDeleteScope()
+		return s.execUpdate(map[string]interface{}{s.quote(field.Column): s.now()})
+	}
+	s.clause.Set(generator.DELETE, s.quote(s.RefTable().Name))
 	sql, vars := s.clause.Build(generator.DELETE, generator.WHERE)
 	result, err := s.Raw(sql, vars...).Exec()
 	if err != nil {
@@ -116,13 +479,44 @@ func (s *Session) Delete() (int64, error) {
 	return result.RowsAffected()
 }
 
-// Count 计数功能实现
+// Unscoped 方法实现链式调用，下一条语句忽略软删除：查询时包括已经被软删除的记录，删除时真正删除记录
+func (s *Session) Unscoped() *Session {
+	s.unscoped = true
+	return s
+}
+
+// softDeleteScope 表有软删除列且没有调用 Unscoped 时，追加 DeletedAt IS NULL 条件，只操作没有被软删除的记录
+// 这个条件和用户设置的所有条件之间是 AND 的关系，不会被 OrWhere 绕过
+func (s *Session) softDeleteScope() {
+	if field := s.RefTable().DeletedAtField; field != nil && !s.unscoped {
+		s.clause.WrapWhere(s.qualifiedColumn(field.Column) + " IS NULL")
+	}
+}
+
+// Count 计数功能实现，调用了 Group 时返回分组的数量
 func (s *Session) Count() (int64, error) {
 	// 调用钩子 BeforeDelete
 	s.CallMethod(BeforeDelete, nil)
 	// 构造子句
-	s.clause.Set(generator.COUNT, s.RefTable().Name)
-	sql, vars := s.clause.Build(generator.COUNT, generator.WHERE)
+	table := s.quote(s.RefTable().Name)
+	s.softDeleteScope()
+	var sql string
+	var vars []interface{}
+	if s.clause.Has(generator.GROUPBY) {
+		// 在子查询中分组，外层对分组计数
+		s.clause.Set(generator.SELECT, table, []string{"1"})
+		sub, subVars := s.clause.Build(generator.SELECT, generator.JOIN, generator.WHERE, generator.GROUPBY, generator.HAVING)
+		s.clause.Set(generator.COUNT, fmt.Sprintf("(%s) AS %s", sub, s.quote("t")))
+		sql, _ = s.clause.Build(generator.COUNT)
+		vars = subVars
+	} else {
+		s.clause.Set(generator.COUNT, table)
+		sql, vars = s.clause.Build(generator.COUNT, generator.JOIN, generator.WHERE)
+	}
+	if err := s.stmtErr(); err != nil {
+		s.Clear()
+		return 0, err
+	}
 	// 最终的结果只是一条数据不是多条
 	row := s.Raw(sql, vars...).QueryRow()
 	var temp int64
@@ -142,10 +536,69 @@ func (s *Session) Limit(num int) *Session {
 	return s
 }
 
-// Where 方法实现链式调用，关键是返回 *Session
-func (s *Session) Where(desc string, args ...interface{}) *Session {
-	var vars []interface{}
-	s.clause.Set(generator.WHERE, append(append(vars, desc), args...)...)
+// Offset 方法实现链式调用，跳过前 num 条记录，没有调用 Limit 时返回之后的所有记录
+func (s *Session) Offset(num int) *Session {
+	s.clause.Set(generator.OFFSET, num)
+	return s
+}
+
+// unboundedLimit 只设置了 OFFSET 时补上 dialect 的无上限的 LIMIT，sqlite3 和 mysql 不支持单独使用 OFFSET
+func (s *Session) unboundedLimit() {
+	if !s.clause.Has(generator.OFFSET) || s.clause.Has(generator.LIMIT) {
+		return
+	}
+	if limit := s.dialect.UnboundedLimit(); limit != "" {
+		s.clause.Set(generator.LIMIT, limit)
+	}
+}
+
+// Joins 方法实现链式调用，追加一个原样拼接的 JOIN 子句，例如
+// s.Joins("LEFT JOIN `Order` ON `Order`.`UserID` = `User`.`ID` AND `Order`.`Amount` > ?", 100)
+func (s *Session) Joins(desc string, args ...interface{}) *Session {
+	s.clause.AddJoin(desc, args...)
+	return s
+}
+
+// InnerJoin 方法实现链式调用，追加 INNER JOIN table ON on
+func (s *Session) InnerJoin(table string, on string, args ...interface{}) *Session {
+	return s.Joins(fmt.Sprintf("INNER JOIN %s ON %s", s.quote(table), on), args...)
+}
+
+// LeftJoin 方法实现链式调用，追加 LEFT JOIN table ON on
+func (s *Session) LeftJoin(table string, on string, args ...interface{}) *Session {
+	return s.Joins(fmt.Sprintf("LEFT JOIN %s ON %s", s.quote(table), on), args...)
+}
+
+// qualifiedColumn 联表查询时在列名前加上 Model 的表名，避免和其他表的同名列冲突
+func (s *Session) qualifiedColumn(column string) string {
+	if s.clause.Has(generator.JOIN) {
+		return s.quote(s.RefTable().Name + "." + column)
+	}
+	return s.quote(column)
+}
+
+// qualifiedColumnOf 将字段名或者列名转换为 qualifiedColumn，匹配不到 Model 的字段时认为传入的已经是列名，原样加上引号
+func (s *Session) qualifiedColumnOf(name string) string {
+	if s.refTable != nil {
+		if field := s.refTable.GetField(name); field != nil {
+			return s.qualifiedColumn(field.Column)
+		}
+		if field := s.refTable.FieldByColumn(name); field != nil {
+			return s.qualifiedColumn(field.Column)
+		}
+	}
+	return s.quote(name)
+}
+
+// Group 方法实现链式调用，设置 GROUP BY 子句
+func (s *Session) Group(desc string) *Session {
+	s.clause.Set(generator.GROUPBY, desc)
+	return s
+}
+
+// Having 方法实现链式调用，设置 HAVING 子句，需要和 Group 一起使用
+func (s *Session) Having(desc string, args ...interface{}) *Session {
+	s.clause.Set(generator.HAVING, append([]interface{}{desc}, args...)...)
 	return s
 }
 
@@ -163,11 +616,148 @@ func (s *Session) First(value interface{}) error {
 	destSlice := reflect.New(reflect.SliceOf(dest.Type())).Elem()
 	// Addr() 方法询问 reflect.Value 变量是否可寻址
 	if err := s.Limit(1).Find(destSlice.Addr().Interface()); err != nil {
-		return nil
+		return err
 	}
 	if destSlice.Len() == 0 {
-		return errors.New("NOT FOUND")
+		return ErrRecordNotFound
 	}
 	dest.Set(destSlice.Index(0))
 	return nil
 }
+
+// primaryCondition 根据主键构造 WHERE 条件，联合主键的各列之间用 AND 连接，keys 的顺序和 PrimaryFields 一致
+func (s *Session) primaryCondition(keys []interface{}) (string, []interface{}, error) {
+	table := s.RefTable()
+	if len(table.PrimaryFields) == 0 {
+		return "", nil, ErrNoPrimaryKey
+	}
+	if len(keys) != len(table.PrimaryFields) {
+		return "", nil, fmt.Errorf("table %s has %d primary keys, but got %d values",
+			table.Name, len(table.PrimaryFields), len(keys))
+	}
+	var conditions []string
+	for _, field := range table.PrimaryFields {
+		conditions = append(conditions, s.qualifiedColumn(field.Column)+" = ?")
+	}
+	return strings.Join(conditions, " AND "), keys, nil
+}
+
+// primaryValues 取出对象中主键字段的值，zero 表示是否所有主键都是零值
+func (s *Session) primaryValues(value interface{}) (keys []interface{}, zero bool) {
+	destValue := reflect.Indirect(reflect.ValueOf(value))
+	zero = true
+	for _, field := range s.RefTable().PrimaryFields {
+		v := field.ValueOf(destValue)
+		keys = append(keys, v.Interface())
+		if !v.IsZero() {
+			zero = false
+		}
+	}
+	return
+}
+
+// Get 根据主键查询一条记录，联合主键时 keys 按字段顺序传入，例如 s.Get(&user, 1)
+func (s *Session) Get(value interface{}, keys ...interface{}) error {
+	s.Model(value)
+	desc, vars, err := s.primaryCondition(keys)
+	if err != nil {
+		return err
+	}
+	return s.Where(desc, vars...).First(value)
+}
+
+// Save 保存一条记录：主键是零值时执行 INSERT，否则根据主键 UPDATE 所有非主键列，数据库中还没有这条记录时再执行 INSERT
+// 表有版本号且对象的版本号不是零值时使用乐观锁，只更新版本号相同的记录并将版本号加一，没有更新到记录时返回 ErrStaleObject
+func (s *Session) Save(value interface{}) (int64, error) {
+	table := s.Model(value).RefTable()
+	if len(table.PrimaryFields) == 0 {
+		return 0, ErrNoPrimaryKey
+	}
+	keys, zero := s.primaryValues(value)
+	if zero {
+		return s.Insert(value)
+	}
+	// 调用钩子 BeforeUpdate，钩子可能会修改对象，所以要在钩子之后再取值
+	s.CallMethod(BeforeUpdate, value)
+	destValue := reflect.Indirect(reflect.ValueOf(value))
+	m := make(map[string]interface{})
+	for _, field := range table.Fields {
+		if !field.PrimaryKey {
+			m[s.quote(field.Column)] = field.DBValueOf(destValue)
+		}
+	}
+	s.setUpdateTime(value, m)
+	keys, _ = s.primaryValues(value)
+	desc, vars, _ := s.primaryCondition(keys)
+	// 主键条件不能被之前的 OrWhere 绕过
+	s.clause.WrapWhere(desc, vars...)
+	dest, version, locked := s.lockVersion(m, value)
+	var affected int64
+	if len(m) > 0 {
+		var err error
+		if affected, err = s.execUpdate(m); err != nil {
+			return 0, err
+		}
+	} else {
+		// 所有列都是主键时没有可以更新的列
+		s.Clear()
+	}
+	if locked {
+		// 版本号不是零值说明记录已经插入过，没有更新到记录是因为被其他会话修改或者删除了
+		if affected == 0 {
+			return 0, ErrStaleObject
+		}
+		setVersion(table.VersionField, dest, version)
+	} else if affected == 0 {
+		// mysql 中记录存在但值没有变化时 RowsAffected 也是 0，所以要确认记录不存在，例如手动指定了主键的新记录，才执行 INSERT
+		exists, err := s.exists(keys)
+		if err != nil {
+			return 0, err
+		}
+		if !exists {
+			return s.Insert(value)
+		}
+	}
+	// 调用钩子 AfterUpdate
+	s.CallMethod(AfterUpdate, value)
+	return affected, nil
+}
+
+// exists 判断主键为 keys 的记录是否存在，已经被软删除的记录也算存在
+func (s *Session) exists(keys []interface{}) (bool, error) {
+	desc, vars, err := s.primaryCondition(keys)
+	if err != nil {
+		return false, err
+	}
+	var one int
+	err = s.Raw(fmt.Sprintf("SELECT 1 FROM %s WHERE %s LIMIT 1", s.quote(s.RefTable().Name), desc), vars...).QueryRow().Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// DeleteObj 根据对象的主键删除对应的记录，主键都是零值时返回 ErrMissingCondition
+func (s *Session) DeleteObj(value interface{}) (int64, error) {
+	s.Model(value)
+	keys, zero := s.primaryValues(value)
+	desc, vars, err := s.primaryCondition(keys)
+	if err != nil {
+		return 0, err
+	}
+	// 主键是零值的对象还没有保存过，不能按零值删除记录
+	if zero {
+		s.Clear()
+		return 0, ErrMissingCondition
+	}
+	// 调用钩子 BeforeDelete
+	s.CallMethod(BeforeDelete, value)
+	s.clause.WrapWhere(desc, vars...)
+	affected, err := s.execDelete()
+	if err != nil {
+		return 0, err
+	}
+	// 调用钩子 AfterDelete
+	s.CallMethod(AfterDelete, value)
+	return affected, nil
+}
@@ -1,6 +1,13 @@
 package session
 
-import "testing"
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
 
 var (
 	user1 = &User{"Tom", 18}
@@ -65,3 +72,684 @@ func TestSession_DeleteAndCount(t *testing.T) {
 		t.Fatal("failed to delete or count")
 	}
 }
+
+func TestSession_Get(t *testing.T) {
+	s := testRecordInit(t)
+	u := &User{}
+	if err := s.Get(u, "Sam"); err != nil || u.Age != 25 {
+		t.Fatal("failed to get record by primary key, got", u)
+	}
+	if err := s.Get(&User{}, "Nobody"); err != ErrRecordNotFound {
+		t.Fatal("expect ErrRecordNotFound, but got", err)
+	}
+}
+
+func TestSession_Save(t *testing.T) {
+	s := testRecordInit(t)
+	// 主键已存在，更新所有非主键列
+	if _, err := s.Save(&User{"Tom", 30}); err != nil {
+		t.Fatal("failed to update record", err)
+	}
+	// 主键不存在，插入新记录
+	if _, err := s.Save(&User{"Jack", 40}); err != nil {
+		t.Fatal("failed to insert record", err)
+	}
+	tom, jack := &User{}, &User{}
+	_ = s.Get(tom, "Tom")
+	_ = s.Get(jack, "Jack")
+	count, _ := s.Count()
+	if tom.Age != 30 || jack.Age != 40 || count != 3 {
+		t.Fatal("failed to save records")
+	}
+}
+
+type Follow struct {
+	From string `gamblerORM:"primary_key"`
+	To   string `gamblerORM:"primary_key"`
+}
+
+func TestSession_SaveScope(t *testing.T) {
+	s := testRecordInit(t)
+	// 之前的 OrWhere 不能绕过主键条件
+	if _, err := s.Where("Name = ?", "Tom").OrWhere("Name = ?", "Sam").Save(&User{"Sam", 30}); err != nil {
+		t.Fatal("failed to save record", err)
+	}
+	tom, sam := &User{}, &User{}
+	_ = s.Get(tom, "Tom")
+	_ = s.Get(sam, "Sam")
+	if tom.Age != 18 || sam.Age != 30 {
+		t.Fatal("Save should only update the record with the same primary key, got", tom, sam)
+	}
+
+	// 所有列都是主键时只在记录不存在时插入
+	s = NewSession().Model(&Follow{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	for i := 0; i < 2; i++ {
+		if _, err := s.Save(&Follow{"a", "b"}); err != nil {
+			t.Fatal("failed to save record with only primary keys", err)
+		}
+	}
+	if count, _ := s.Count(); count != 1 {
+		t.Fatal("Save should not insert an existing record again, got", count)
+	}
+}
+
+func TestSession_DeleteObj(t *testing.T) {
+	s := NewSession().Model(&Member{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	_, _ = s.Insert(&Member{1, 1, "owner"}, &Member{1, 2, "guest"}, &Member{2, 1, "guest"})
+	// 联合主键，只删除 GroupID = 1 AND UserID = 2 的记录
+	affected, err := s.DeleteObj(&Member{GroupID: 1, UserID: 2})
+	count, _ := s.Count()
+	if err != nil || affected != 1 || count != 2 {
+		t.Fatal("failed to delete record by composite primary key")
+	}
+	type Log struct {
+		Content string
+	}
+	if _, err := NewSession().DeleteObj(&Log{"hello"}); err != ErrNoPrimaryKey {
+		t.Fatal("expect ErrNoPrimaryKey, but got", err)
+	}
+	if _, err := s.DeleteObj(&Member{}); err != ErrMissingCondition {
+		t.Fatal("expect ErrMissingCondition for zero primary key, but got", err)
+	}
+}
+
+type Order struct {
+	ID     int64 `gamblerORM:"primary_key;auto_increment"`
+	Amount int
+}
+
+func TestSession_InsertAutoIncrement(t *testing.T) {
+	s := NewSession().Model(&Order{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	o1 := &Order{Amount: 10}
+	if _, err := s.Insert(o1); err != nil || o1.ID != 1 {
+		t.Fatal("failed to write back auto increment id, got", o1.ID)
+	}
+	// 多行插入，自增列为零值和非零值的记录混在一起
+	o2, o3, o4 := &Order{Amount: 20}, &Order{ID: 100, Amount: 30}, &Order{Amount: 40}
+	affected, err := s.Insert(o2, o3, o4)
+	if err != nil || affected != 3 {
+		t.Fatal("failed to insert orders", err)
+	}
+	if o2.ID != 101 || o3.ID != 100 || o4.ID != 102 {
+		t.Fatal("failed to write back auto increment ids, got", o2.ID, o3.ID, o4.ID)
+	}
+	got := &Order{}
+	if err := s.Get(got, o4.ID); err != nil || got.Amount != 40 {
+		t.Fatal("failed to get order by written back id")
+	}
+}
+
+type Profile struct {
+	ID       int64 `gamblerORM:"primary_key;auto_increment"`
+	Name     string
+	Age      int
+	Password string
+}
+
+func testProfileInit(t *testing.T) (*Session, *Profile) {
+	t.Helper()
+	s := NewSession().Model(&Profile{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	p := &Profile{Name: "Tom", Age: 18, Password: "123456"}
+	if _, err := s.Insert(p); err != nil {
+		t.Fatal("failed init test profile")
+	}
+	return s, p
+}
+
+func TestSession_Updates(t *testing.T) {
+	s, p := testProfileInit(t)
+	// 只更新非零值字段，主键来自 Model 传入的对象
+	affected, err := s.Model(p).Updates(Profile{Name: "Sam"})
+	got := &Profile{}
+	_ = s.Get(got, p.ID)
+	if err != nil || affected != 1 || got.Name != "Sam" || got.Age != 18 || got.Password != "123456" {
+		t.Fatal("failed to update non-zero fields, got", got)
+	}
+	// Select 选中的字段即使是零值也会更新，Omit 排除的字段不会更新
+	_, err = s.Model(p).Select("Age", "Password").Omit("Password").Updates(&Profile{Password: "qwerty"})
+	got = &Profile{}
+	_ = s.Get(got, p.ID)
+	if err != nil || got.Age != 0 || got.Password != "123456" {
+		t.Fatal("failed to update selected fields, got", got)
+	}
+	// 没有主键也没有条件时不更新整张表
+	if _, err := s.Model(&Profile{}).Updates(Profile{Name: "Jack"}); err != ErrMissingCondition {
+		t.Fatal("expect ErrMissingCondition, but got", err)
+	}
+	// 之前的 OrWhere 不能绕过主键条件
+	other := &Profile{Name: "Sam", Age: 20}
+	_, _ = s.Insert(other)
+	_, err = s.Where("Name = ?", "Sam").OrWhere("Age > ?", 100).Model(p).Updates(Profile{Age: 30})
+	got = &Profile{}
+	_ = s.Get(got, other.ID)
+	if err != nil || got.Age != 20 {
+		t.Fatal("Updates should only update the record with the primary key, got", got, err)
+	}
+}
+
+func TestSession_UpdatesMap(t *testing.T) {
+	s, p := testProfileInit(t)
+	other := &Profile{Name: "Sam", Age: 20}
+	_, _ = s.Insert(other)
+	// map 同样使用 Model 传入的对象的主键作为条件，不会更新其他记录
+	affected, err := s.Model(p).Updates(map[string]interface{}{"Age": 30})
+	if err != nil || affected != 1 {
+		t.Fatal("failed to update with map, got", affected, err)
+	}
+	got := &Profile{}
+	_ = s.Get(got, other.ID)
+	if got.Age != 20 {
+		t.Fatal("Updates with map should only update the record with the primary key, got", got)
+	}
+	got = &Profile{}
+	_ = s.Get(got, p.ID)
+	if got.Age != 30 {
+		t.Fatal("failed to update with map, got", got)
+	}
+	// 没有主键也没有条件时不更新整张表
+	if _, err := s.Model(&Profile{}).Updates(map[string]interface{}{"Age": 40}); err != ErrMissingCondition {
+		t.Fatal("expect ErrMissingCondition, but got", err)
+	}
+	if affected, err := s.Model(&Profile{}).Where("Name = ?", "Sam").Updates(map[string]interface{}{"Age": 40}); err != nil || affected != 1 {
+		t.Fatal("failed to update with map and where, got", affected, err)
+	}
+}
+
+func TestSession_SelectFind(t *testing.T) {
+	s, _ := testProfileInit(t)
+	var profiles []Profile
+	if err := s.Select("Name", "Age").Find(&profiles); err != nil || len(profiles) != 1 {
+		t.Fatal("failed to query selected columns", err)
+	}
+	if p := profiles[0]; p.Name != "Tom" || p.Age != 18 || p.ID != 0 || p.Password != "" {
+		t.Fatal("failed to scan selected columns, got", p)
+	}
+	// Select 只对一条语句生效
+	got := &Profile{}
+	if err := s.Omit("Password").First(got); err != nil || got.ID != 1 || got.Name != "Tom" || got.Password != "" {
+		t.Fatal("failed to query without omitted columns, got", got)
+	}
+	if err := s.Omit("ID", "Name", "Age", "Password").Find(&profiles); err != ErrNoColumnSelected {
+		t.Fatal("expect ErrNoColumnSelected, but got", err)
+	}
+}
+
+func TestSession_Joins(t *testing.T) {
+	_, _ = testProfileInit(t)
+	s := testOrderInit(t, 3)
+	// Order 和 Profile 都有 ID 列，联表后 Find 只查询 Order 的列
+	var orders []Order
+	err := s.InnerJoin("Profile", "`Profile`.`ID` = `Order`.`ID`").Where("`Profile`.`Name` = ?", "Tom").Find(&orders)
+	if err != nil || len(orders) != 1 || orders[0].ID != 1 || orders[0].Amount != 10 {
+		t.Fatal("failed to find with inner join, got", orders, err)
+	}
+	count, err := s.Model(&Order{}).LeftJoin("Profile", "`Profile`.`ID` = `Order`.`ID`").Where("`Profile`.`ID` IS NULL").Count()
+	if err != nil || count != 2 {
+		t.Fatal("failed to count with left join, got", count, err)
+	}
+	var stats []struct {
+		ID   int64
+		Name string
+	}
+	err = s.Model(&Order{}).Select("ID", "`Profile`.`Name` AS Name").
+		Joins("INNER JOIN `Profile` ON `Profile`.`ID` = `Order`.`ID` AND `Order`.`Amount` >= ?", 10).Scan(&stats)
+	if err != nil || len(stats) != 1 || stats[0].ID != 1 || stats[0].Name != "Tom" {
+		t.Fatal("failed to scan with joins, got", stats, err)
+	}
+}
+
+func TestSession_JoinsQualifiedColumn(t *testing.T) {
+	_, _ = testProfileInit(t)
+	s := testOrderInit(t, 3)
+	join := func() *Session {
+		return s.Model(&Order{}).LeftJoin("Profile", "`Profile`.`ID` = `Order`.`ID`")
+	}
+	// Order 和 Profile 都有 ID 列，条件中的列名要加上 Model 的表名，否则会报列名不明确
+	var orders []Order
+	if err := join().In("ID", []int64{1, 2}).Between("ID", 2, 3).Find(&orders); err != nil || len(orders) != 1 || orders[0].ID != 2 {
+		t.Fatal("failed to find with qualified condition, got", orders, err)
+	}
+	order := &Order{}
+	if err := join().Get(order, 3); err != nil || order.Amount != 30 {
+		t.Fatal("failed to get with join, got", order, err)
+	}
+	if total, err := join().Where("`Profile`.`ID` IS NULL").Sum("ID"); err != nil || total != 5 {
+		t.Fatal("failed to sum with join, got", total, err)
+	}
+	var batches int
+	err := join().FindInBatches(2, func(batch interface{}) error {
+		batches++
+		return nil
+	})
+	if err != nil || batches != 2 {
+		t.Fatal("failed to find in batches with join, got", batches, err)
+	}
+}
+
+type Base struct {
+	ID   int64 `gamblerORM:"primary_key;auto_increment"`
+	Note string
+}
+
+type Address struct {
+	City   string
+	Street string
+}
+
+type Shop struct {
+	*Base
+	Name    string
+	Address Address `gamblerORM:"embedded;embeddedPrefix:addr_"`
+}
+
+func TestSession_Embedded(t *testing.T) {
+	s := NewSession().Model(&Shop{})
+	_ = s.DropTable()
+	if err := s.CreateTable(); err != nil {
+		t.Fatal("failed to create table with embedded structs", err)
+	}
+	shop := &Shop{Name: "Tom", Address: Address{City: "Beijing", Street: "Chang'an"}}
+	if _, err := s.Insert(shop); err != nil || shop.Base == nil || shop.ID != 1 {
+		t.Fatal("failed to insert and write back id through nil embedded pointer", err)
+	}
+	if _, err := s.Model(shop).Updates(Shop{Address: Address{City: "Shanghai"}}); err != nil {
+		t.Fatal("failed to update embedded field", err)
+	}
+	var shops []Shop
+	if err := s.Find(&shops); err != nil || len(shops) != 1 || shops[0].Base == nil || shops[0].ID != 1 ||
+		shops[0].Address.City != "Shanghai" || shops[0].Address.Street != "Chang'an" {
+		t.Fatal("failed to find through nested path, got", shops, err)
+	}
+	var scanned Shop
+	if err := s.Raw("SELECT * FROM Shop").Scan(&scanned); err != nil || scanned.ID != 1 || scanned.Address.City != "Shanghai" {
+		t.Fatal("failed to scan into embedded structs, got", scanned, err)
+	}
+}
+
+type Branch struct {
+	Base
+	Note string
+	Home Address `gamblerORM:"embedded;embeddedPrefix:home_"`
+	Work Address `gamblerORM:"embedded;embeddedPrefix:work_"`
+}
+
+func TestSession_EmbeddedShadow(t *testing.T) {
+	s := NewSession().Model(&Branch{})
+	_ = s.DropTable()
+	if err := s.CreateTable(); err != nil {
+		t.Fatal("failed to create table with shadowed fields", err)
+	}
+	branch := &Branch{Base: Base{Note: "inner"}, Note: "outer", Home: Address{City: "Beijing"}, Work: Address{City: "Shanghai"}}
+	if _, err := s.Insert(branch); err != nil || branch.ID != 1 {
+		t.Fatal("failed to insert with shadowed fields", err)
+	}
+	var found Branch
+	if err := s.First(&found); err != nil || found.Note != "outer" || found.Base.Note != "" ||
+		found.Home.City != "Beijing" || found.Work.City != "Shanghai" {
+		t.Fatal("failed to find with shadowed fields, got", found, err)
+	}
+	var scanned Branch
+	if err := s.Raw("SELECT * FROM Branch").Scan(&scanned); err != nil || scanned.Note != "outer" || scanned.Base.Note != "" {
+		t.Fatal("Scan should follow the same shadowing rules, got", scanned, err)
+	}
+}
+
+type Contact struct {
+	ID       int64 `gamblerORM:"primary_key;auto_increment"`
+	Nick     *string
+	Age      *int
+	Birthday *time.Time
+	Email    sql.NullString
+}
+
+func TestSession_Nullable(t *testing.T) {
+	s := NewSession().Model(&Contact{})
+	_ = s.DropTable()
+	if err := s.CreateTable(); err != nil {
+		t.Fatal("failed to create table with nullable columns", err)
+	}
+	nick, age, birthday := "Tom", 0, time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	c1 := &Contact{}
+	c2 := &Contact{Nick: &nick, Age: &age, Birthday: &birthday, Email: sql.NullString{String: "tom@example.com", Valid: true}}
+	if _, err := s.Insert(c1, c2); err != nil {
+		t.Fatal("failed to insert nullable values", err)
+	}
+	if count, _ := s.Model(&Contact{}).IsNull("Nick").IsNull("Age").IsNull("Birthday").IsNull("Email").Count(); count != 1 {
+		t.Fatal("nil pointers and invalid sql.NullString should be written as NULL, got", count)
+	}
+	var contacts []Contact
+	if err := s.OrderBy("ID").Find(&contacts); err != nil || len(contacts) != 2 {
+		t.Fatal("failed to find nullable values", err)
+	}
+	if c := contacts[0]; c.Nick != nil || c.Age != nil || c.Birthday != nil || c.Email.Valid {
+		t.Fatal("NULL should be scanned into nil pointers, got", c)
+	}
+	if c := contacts[1]; c.Nick == nil || *c.Nick != "Tom" || c.Age == nil || *c.Age != 0 ||
+		c.Birthday == nil || !c.Birthday.Equal(birthday) || c.Email.String != "tom@example.com" {
+		t.Fatal("failed to scan non-NULL values into pointers, got", c)
+	}
+}
+
+type Money struct {
+	Cents int64
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return m.Cents, nil
+}
+
+func (m *Money) Scan(src interface{}) error {
+	cents, ok := src.(int64)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into Money", src)
+	}
+	m.Cents = cents
+	return nil
+}
+
+type Status int
+
+const (
+	Active Status = iota + 1
+	Blocked
+)
+
+var statusNames = map[Status]string{Active: "active", Blocked: "blocked"}
+
+func (s Status) Value() (driver.Value, error) {
+	return statusNames[s], nil
+}
+
+func (s *Status) Scan(src interface{}) error {
+	name := fmt.Sprint(src)
+	if b, ok := src.([]byte); ok {
+		name = string(b)
+	}
+	for status, n := range statusNames {
+		if n == name {
+			*s = status
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown status %q", name)
+}
+
+type Wallet struct {
+	ID      int64 `gamblerORM:"primary_key;auto_increment"`
+	Balance Money
+	Status  Status
+}
+
+func TestSession_CustomTypes(t *testing.T) {
+	s := NewSession().Model(&Wallet{})
+	balance, status := s.RefTable().GetField("Balance").Type, s.RefTable().GetField("Status").Type
+	if balance != "bigint" || status != "text" {
+		t.Fatal("failed to infer types from driver.Valuer, got", balance, status)
+	}
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	if _, err := s.Insert(&Wallet{Balance: Money{1999}, Status: Blocked}); err != nil {
+		t.Fatal("failed to insert custom types", err)
+	}
+	var wallet Wallet
+	if err := s.Where("Status = ?", Blocked).First(&wallet); err != nil || wallet.Balance.Cents != 1999 || wallet.Status != Blocked {
+		t.Fatal("failed to scan custom types, got", wallet, err)
+	}
+}
+
+type Preference struct {
+	ID     int64             `gamblerORM:"primary_key;auto_increment"`
+	Tags   []string          `gamblerORM:"serializer:json"`
+	Limits map[string]int    `gamblerORM:"serializer:json"`
+	Extras map[string]string `gamblerORM:"serializer:gob"`
+}
+
+func TestSession_Serializer(t *testing.T) {
+	s := NewSession().Model(&Preference{})
+	_ = s.DropTable()
+	if err := s.CreateTable(); err != nil {
+		t.Fatal("failed to create table with serializer fields", err)
+	}
+	p := &Preference{Tags: []string{"go", "orm"}, Limits: map[string]int{"daily": 10}, Extras: map[string]string{"lang": "zh"}}
+	if _, err := s.Insert(p, &Preference{}); err != nil {
+		t.Fatal("failed to insert serializer fields", err)
+	}
+	if _, err := s.Model(p).Updates(Preference{Tags: []string{"sql"}}); err != nil {
+		t.Fatal("failed to update serializer fields", err)
+	}
+	var prefs []Preference
+	if err := s.OrderBy("ID").Find(&prefs); err != nil || len(prefs) != 2 {
+		t.Fatal("failed to find serializer fields", err)
+	}
+	if !reflect.DeepEqual(prefs[0].Tags, []string{"sql"}) || prefs[0].Limits["daily"] != 10 || prefs[0].Extras["lang"] != "zh" {
+		t.Fatal("failed to decode serializer fields, got", prefs[0])
+	}
+	if prefs[1].Tags != nil || prefs[1].Limits != nil || prefs[1].Extras != nil {
+		t.Fatal("zero values should be decoded back to nil, got", prefs[1])
+	}
+}
+
+type Note struct {
+	ID        int64 `gamblerORM:"primary_key;auto_increment"`
+	Title     string
+	DeletedAt *time.Time
+}
+
+func TestSession_SoftDelete(t *testing.T) {
+	s := NewSession().Model(&Note{})
+	_ = s.DropTable()
+	if err := s.CreateTable(); err != nil {
+		t.Fatal("failed to create table with soft delete", err)
+	}
+	n1, n2, n3 := &Note{Title: "a"}, &Note{Title: "b"}, &Note{Title: "c"}
+	if _, err := s.Insert(n1, n2, n3); err != nil {
+		t.Fatal("failed to insert notes", err)
+	}
+	if affected, err := s.Where("Title = ?", "a").Delete(); err != nil || affected != 1 {
+		t.Fatal("failed to soft delete", affected, err)
+	}
+	if affected, err := s.DeleteObj(n2); err != nil || affected != 1 {
+		t.Fatal("failed to soft delete object", affected, err)
+	}
+	// 已经被软删除的记录不会被再次删除
+	if affected, _ := s.Where("Title = ?", "a").Delete(); affected != 0 {
+		t.Fatal("soft deleted record should not be deleted again")
+	}
+
+	var notes []Note
+	if err := s.Find(&notes); err != nil || len(notes) != 1 || notes[0].Title != "c" {
+		t.Fatal("soft deleted records should be excluded from Find", notes, err)
+	}
+	if count, _ := s.Count(); count != 1 {
+		t.Fatal("soft deleted records should be excluded from Count, got", count)
+	}
+	var note Note
+	if err := s.Where("Title = ?", "a").First(&note); err != ErrRecordNotFound {
+		t.Fatal("soft deleted record should not be found by First", err)
+	}
+	notes = nil
+	if err := s.Where("Title = ?", "a").OrWhere("Title = ?", "b").Find(&notes); err != nil || len(notes) != 0 {
+		t.Fatal("OrWhere should not bypass soft delete", notes)
+	}
+
+	notes = nil
+	if err := s.Unscoped().OrderBy("ID").Find(&notes); err != nil || len(notes) != 3 {
+		t.Fatal("Unscoped should include soft deleted records", notes, err)
+	}
+	if notes[0].DeletedAt == nil || notes[2].DeletedAt != nil {
+		t.Fatal("failed to set DeletedAt", notes)
+	}
+	if affected, err := s.Unscoped().Where("Title = ?", "a").Delete(); err != nil || affected != 1 {
+		t.Fatal("failed to hard delete", affected, err)
+	}
+	if count, _ := s.Unscoped().Count(); count != 2 {
+		t.Fatal("Unscoped Delete should remove the record, got", count)
+	}
+}
+
+type Post struct {
+	ID        int64 `gamblerORM:"primary_key;auto_increment"`
+	Title     string
+	CreatedAt time.Time
+	UpdatedAt int64 `gamblerORM:"autoUpdateTime:milli"`
+	Published int64 `gamblerORM:"autoCreateTime"`
+}
+
+func TestSession_AutoTime(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	s := NewSession().Model(&Post{})
+	s.SetNowFunc(func() time.Time { return now })
+	_ = s.DropTable()
+	if err := s.CreateTable(); err != nil {
+		t.Fatal("failed to create table with auto time", err)
+	}
+	created := now.Add(-time.Hour)
+	p1, p2 := &Post{Title: "a"}, &Post{Title: "b", CreatedAt: created}
+	if _, err := s.Insert(p1, p2); err != nil {
+		t.Fatal("failed to insert posts", err)
+	}
+	if !p1.CreatedAt.Equal(now) || p1.UpdatedAt != now.UnixNano()/1e6 || p1.Published != now.Unix() {
+		t.Fatal("failed to set auto time on insert, got", p1)
+	}
+	if !p2.CreatedAt.Equal(created) {
+		t.Fatal("non-zero CreatedAt should be kept, got", p2.CreatedAt)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := s.Model(p1).Updates(Post{Title: "c"}); err != nil {
+		t.Fatal("failed to update post", err)
+	}
+	if p1.UpdatedAt != now.UnixNano()/1e6 {
+		t.Fatal("Updates should refresh UpdatedAt of Model, got", p1.UpdatedAt)
+	}
+	now = now.Add(time.Minute)
+	if _, err := s.Where("ID = ?", p2.ID).Update("Title", "d"); err != nil {
+		t.Fatal("failed to update post", err)
+	}
+	var post Post
+	if err := s.Get(&post, p2.ID); err != nil || post.UpdatedAt != now.UnixNano()/1e6 || !post.CreatedAt.Equal(created) {
+		t.Fatal("Update should refresh UpdatedAt only, got", post, err)
+	}
+	now = now.Add(time.Minute)
+	post.Title = "e"
+	if _, err := s.Save(&post); err != nil || post.UpdatedAt != now.UnixNano()/1e6 {
+		t.Fatal("Save should refresh UpdatedAt, got", post.UpdatedAt, err)
+	}
+	if err := s.Get(&post, p2.ID); err != nil || post.UpdatedAt != now.UnixNano()/1e6 || post.Published != p2.Published {
+		t.Fatal("failed to save UpdatedAt, got", post, err)
+	}
+}
+
+type Stock struct {
+	ID       int64 `gamblerORM:"primary_key;auto_increment"`
+	Quantity int
+	Version  int64 `gamblerORM:"version"`
+}
+
+func TestSession_OptimisticLock(t *testing.T) {
+	s := NewSession().Model(&Stock{})
+	_ = s.DropTable()
+	if err := s.CreateTable(); err != nil {
+		t.Fatal("failed to create table with version", err)
+	}
+	stock := &Stock{Quantity: 10}
+	if _, err := s.Insert(stock); err != nil || stock.Version != 1 {
+		t.Fatal("failed to initialize version on insert, got", stock, err)
+	}
+
+	// 两个会话读到同一个版本的记录，先提交的成功，后提交的返回 ErrStaleObject
+	s1, s2 := NewSession(), NewSession()
+	var a, b Stock
+	if err := s1.Get(&a, stock.ID); err != nil {
+		t.Fatal("failed to get stock", err)
+	}
+	if err := s2.Get(&b, stock.ID); err != nil {
+		t.Fatal("failed to get stock", err)
+	}
+	a.Quantity = 9
+	if _, err := s1.Save(&a); err != nil || a.Version != 2 {
+		t.Fatal("failed to save with version, got", a, err)
+	}
+	b.Quantity = 8
+	if _, err := s2.Save(&b); err != ErrStaleObject || b.Version != 1 {
+		t.Fatal("expect ErrStaleObject for stale Save, got", b, err)
+	}
+	if _, err := s2.Model(&b).Updates(Stock{Quantity: 7}); err != ErrStaleObject {
+		t.Fatal("expect ErrStaleObject for stale Updates, got", err)
+	}
+
+	// 重新读取后可以继续更新
+	if err := s2.Get(&b, stock.ID); err != nil || b.Quantity != 9 || b.Version != 2 {
+		t.Fatal("stale updates should not modify record, got", b, err)
+	}
+	if affected, err := s2.Model(&b).Updates(Stock{Quantity: 7}); err != nil || affected != 1 || b.Version != 3 {
+		t.Fatal("failed to update with version, got", b, err)
+	}
+	var c Stock
+	if err := s.Get(&c, stock.ID); err != nil || c.Quantity != 7 || c.Version != 3 {
+		t.Fatal("failed to increase version, got", c, err)
+	}
+}
+
+func TestSession_OptimisticLockWithOrWhere(t *testing.T) {
+	s := NewSession().Model(&Stock{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	stock := &Stock{Quantity: 10}
+	if _, err := s.Insert(stock); err != nil {
+		t.Fatal("failed to insert stock", err)
+	}
+	s1, s2 := NewSession(), NewSession()
+	var a, b Stock
+	_ = s1.Get(&a, stock.ID)
+	_ = s2.Get(&b, stock.ID)
+	a.Quantity = 9
+	if _, err := s1.Save(&a); err != nil {
+		t.Fatal("failed to save with version", err)
+	}
+	// 调用方残留的 OrWhere 条件不能绕过版本号检查
+	b.Quantity = 8
+	if _, err := s2.Where("Quantity = ?", 9).OrWhere("Quantity > ?", 100).Save(&b); err != ErrStaleObject {
+		t.Fatal("expect ErrStaleObject for stale Save with OrWhere, got", err)
+	}
+	if _, err := s2.Where("Quantity = ?", 9).OrWhere("Quantity > ?", 100).Model(&b).Updates(Stock{Quantity: 7}); err != ErrStaleObject {
+		t.Fatal("expect ErrStaleObject for stale Updates with OrWhere, got", err)
+	}
+	var c Stock
+	if err := s.Get(&c, stock.ID); err != nil || c.Quantity != 9 || c.Version != 2 {
+		t.Fatal("stale updates should not modify record, got", c, err)
+	}
+}
+
+func TestSession_OptimisticLockUpdatesMap(t *testing.T) {
+	s := NewSession().Model(&Stock{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	stock := &Stock{Quantity: 10}
+	if _, err := s.Insert(stock); err != nil {
+		t.Fatal("failed to insert stock", err)
+	}
+	s1, s2 := NewSession(), NewSession()
+	var a, b Stock
+	_ = s1.Get(&a, stock.ID)
+	_ = s2.Get(&b, stock.ID)
+	if _, err := s1.Model(&a).Updates(map[string]interface{}{"Quantity": 9}); err != nil || a.Version != 2 {
+		t.Fatal("failed to update map with version, got", a, err)
+	}
+	// map 中的版本号会被忽略，过期的对象不能覆盖已经更新的记录
+	if _, err := s2.Model(&b).Updates(map[string]interface{}{"Quantity": 8, "Version": 100}); err != ErrStaleObject || b.Version != 1 {
+		t.Fatal("expect ErrStaleObject for stale map Updates, got", b, err)
+	}
+	var c Stock
+	if err := s.Get(&c, stock.ID); err != nil || c.Quantity != 9 || c.Version != 2 {
+		t.Fatal("stale map updates should not modify record, got", c, err)
+	}
+}
@@ -0,0 +1,158 @@
+package session
+
+import (
+	"database/sql"
+	"errors"
+	"gamblerORM/generator"
+	"gamblerORM/schema"
+	"reflect"
+)
+
+// 用于放置逐行读取查询结果的代码。Find 会把所有记录放进切片，导出大量数据时内存占用过高，
+// Rows 每次只读取一行，FindInBatches 每次只查询一批
+
+var ErrBatchClause = errors.New("FindInBatches needs a positive batch size and no Limit, Offset, or OrderBy on a single primary key")
+
+// Rows 是查询结果的迭代器，用法和 sql.Rows 相同
+//
+//	rows, err := s.Model(&User{}).Where("Age > ?", 18).Rows()
+//	defer rows.Close()
+//	for rows.Next() {
+//		var u User
+//		err = rows.Scan(&u)
+//	}
+type Rows struct {
+	s      *Session
+	rows   *sql.Rows
+	fields []*schema.Field // 查询的列对应的字段
+}
+
+// Rows 根据 Model 和链式调用设置的条件执行查询，返回逐行读取结果的迭代器
+func (s *Session) Rows() (*Rows, error) {
+	// 调用钩子 BeforeQuery
+	s.CallMethod(BeforeQuery, nil)
+	// 只查询被选中的列，执行查询后会清空 Select、Omit，所以要提前记录下来
+	fields := s.selectedFields()
+	if len(fields) == 0 {
+		s.Clear()
+		return nil, ErrNoColumnSelected
+	}
+	var columns []string
+	for _, field := range fields {
+		columns = append(columns, s.qualifiedColumn(field.Column))
+	}
+	//开始构建子句
+	s.clause.Set(generator.SELECT, s.quote(s.RefTable().Name), columns)
+	s.softDeleteScope()
+	s.unboundedLimit()
+	sql, vars := s.clause.Build(generator.SELECT, generator.JOIN, generator.WHERE, generator.GROUPBY, generator.HAVING,
+		generator.ORDERBY, generator.LIMIT, generator.OFFSET)
+	rows, err := s.Raw(sql, vars...).QueryRows()
+	if err != nil {
+		return nil, err
+	}
+	return &Rows{s: s, rows: rows, fields: fields}, nil
+}
+
+// Next 准备读取下一行，没有更多记录或者发生错误时返回 false
+func (r *Rows) Next() bool {
+	return r.rows.Next()
+}
+
+// Scan 将当前行写入 dest，dest 是 Model 对应的结构体的指针，写入后调用钩子 AfterQuery
+func (r *Rows) Scan(dest interface{}) error {
+	destValue := reflect.Indirect(reflect.ValueOf(dest))
+	var values []interface{}
+	for _, field := range r.fields {
+		values = append(values, field.ScanTarget(destValue))
+	}
+	// 调用 rows.Scan() 将该行记录每一列的值依次赋值给 values 中的每一个字段
+	if err := r.rows.Scan(values...); err != nil {
+		return err
+	}
+	// 调用钩子 AfterQuery
+	r.s.CallMethod(AfterQuery, dest)
+	return nil
+}
+
+// Err 返回遍历过程中发生的错误
+func (r *Rows) Err() error {
+	return r.rows.Err()
+}
+
+// Close 关闭迭代器，释放数据库连接
+func (r *Rows) Close() error {
+	return r.rows.Close()
+}
+
+// FindInBatches 分批查询 Model 对应的记录，每批最多 batchSize 条，fn 的参数 batch 是 Model 对应的结构体切片，例如 []User
+// 1）Model 只有一个主键时使用 WHERE 主键 > 上一批的最大主键 ORDER BY 主键 LIMIT batchSize 翻页，不受数据量影响，不能再调用 OrderBy
+// 2）否则使用 LIMIT batchSize OFFSET n 翻页，需要通过 OrderBy 保证顺序稳定
+// 链式调用设置的条件对每一批都生效，fn 返回错误时停止查询并返回该错误
+// 每一批的 LIMIT、OFFSET 由 FindInBatches 决定，batchSize 不是正数或者调用了 Limit、Offset 时返回 ErrBatchClause
+func (s *Session) FindInBatches(batchSize int, fn func(batch interface{}) error) error {
+	table := s.RefTable()
+	var pk *schema.Field
+	if len(table.PrimaryFields) == 1 {
+		pk = table.PrimaryFields[0]
+	}
+	if batchSize <= 0 || s.clause.Has(generator.LIMIT) || s.clause.Has(generator.OFFSET) ||
+		(pk != nil && s.clause.Has(generator.ORDERBY)) {
+		s.Clear()
+		return ErrBatchClause
+	}
+	modelType := reflect.Indirect(reflect.ValueOf(table.Model)).Type()
+	// 每一批查询之后都会清空子句，所以要先保存下链式调用设置的条件
+	clause := s.clause.Clone()
+	selects, omits, unscoped := s.selects, s.omits, s.unscoped
+	var last interface{}
+	for offset := 0; ; offset += batchSize {
+		s.clause = clause.Clone()
+		s.selects, s.omits, s.unscoped = selects, omits, unscoped
+		if pk != nil {
+			// 主键必须被查询出来，才能作为下一批的起点
+			if len(s.selects) > 0 {
+				s.selects = append(s.selects[:len(s.selects):len(s.selects)], pk.Name)
+			}
+			// 起点条件不能被之前的 OrWhere 绕过，否则每一批都会查到相同的记录
+			if last != nil {
+				s.clause.WrapWhere(s.qualifiedColumn(pk.Column)+" > ?", last)
+			}
+			s.clause.Set(generator.ORDERBY, s.qualifiedColumn(pk.Column)+" ASC")
+		} else {
+			s.clause.Set(generator.OFFSET, offset)
+		}
+		s.clause.Set(generator.LIMIT, batchSize)
+
+		batch := reflect.MakeSlice(reflect.SliceOf(modelType), 0, batchSize)
+		rows, err := s.Rows()
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			dest := reflect.New(modelType)
+			if err := rows.Scan(dest.Interface()); err != nil {
+				_ = rows.Close()
+				return err
+			}
+			batch = reflect.Append(batch, dest.Elem())
+		}
+		_ = rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if batch.Len() == 0 {
+			return nil
+		}
+		if pk != nil {
+			last = pk.ValueOf(batch.Index(batch.Len() - 1)).Interface()
+		}
+		if err := fn(batch.Interface()); err != nil {
+			return err
+		}
+		// 不足一批说明已经是最后一批
+		if batch.Len() < batchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package session
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func testOrderInit(t *testing.T, n int) *Session {
+	t.Helper()
+	s := NewSession().Model(&Order{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	var orders []interface{}
+	for i := 1; i <= n; i++ {
+		orders = append(orders, &Order{Amount: i * 10})
+	}
+	if _, err := s.Insert(orders...); err != nil {
+		t.Fatal("failed init test orders", err)
+	}
+	return s
+}
+
+func TestSession_Rows(t *testing.T) {
+	s := testOrderInit(t, 5)
+	rows, err := s.Model(&Order{}).Where("Amount > ?", 20).OrderBy("ID DESC").Rows()
+	if err != nil {
+		t.Fatal("failed to query rows", err)
+	}
+	defer rows.Close()
+	var ids []int64
+	for rows.Next() {
+		var o Order
+		if err := rows.Scan(&o); err != nil {
+			t.Fatal("failed to scan row", err)
+		}
+		ids = append(ids, o.ID)
+	}
+	if rows.Err() != nil || len(ids) != 3 || ids[0] != 5 || ids[2] != 3 {
+		t.Fatal("failed to iterate rows, got", ids)
+	}
+}
+
+func TestSession_FindInBatches(t *testing.T) {
+	s := testOrderInit(t, 7)
+	var sizes []int
+	total := 0
+	err := s.Model(&Order{}).Where("Amount > ?", 10).FindInBatches(2, func(batch interface{}) error {
+		orders := batch.([]Order)
+		sizes = append(sizes, len(orders))
+		for _, o := range orders {
+			total += o.Amount
+		}
+		return nil
+	})
+	// Amount 为 20 ~ 70 的 6 条记录，分为 3 批
+	if err != nil || len(sizes) != 3 || sizes[2] != 2 || total != 270 {
+		t.Fatal("failed to find in batches, got", sizes, total, err)
+	}
+	stop := errors.New("stop")
+	count := 0
+	err = s.Model(&Order{}).FindInBatches(3, func(batch interface{}) error {
+		count++
+		return stop
+	})
+	if err != stop || count != 1 {
+		t.Fatal("expect FindInBatches to stop on error")
+	}
+}
+
+func TestSession_FindInBatchesScope(t *testing.T) {
+	s := testOrderInit(t, 7)
+	// 起点条件不能被 OrWhere 绕过，否则会一直查询到 Amount 为 10 的记录
+	var amounts []int
+	err := s.Model(&Order{}).Where("Amount > ?", 60).OrWhere("Amount < ?", 20).FindInBatches(1, func(batch interface{}) error {
+		for _, o := range batch.([]Order) {
+			amounts = append(amounts, o.Amount)
+		}
+		if len(amounts) > 2 {
+			return errors.New("too many batches")
+		}
+		return nil
+	})
+	if err != nil || !reflect.DeepEqual(amounts, []int{10, 70}) {
+		t.Fatal("failed to find in batches with OrWhere, got", amounts, err)
+	}
+	fn := func(batch interface{}) error { return nil }
+	for _, size := range []int{0, -1} {
+		if err := s.Model(&Order{}).FindInBatches(size, fn); err != ErrBatchClause {
+			t.Fatal("expect ErrBatchClause for batch size", size, err)
+		}
+	}
+	if err := s.Model(&Order{}).Limit(3).FindInBatches(2, fn); err != ErrBatchClause {
+		t.Fatal("expect ErrBatchClause with Limit, got", err)
+	}
+	if err := s.Model(&Order{}).OrderBy("Amount DESC").FindInBatches(2, fn); err != ErrBatchClause {
+		t.Fatal("expect ErrBatchClause with OrderBy, got", err)
+	}
+}
+
+func TestSession_FindInBatchesWithOffset(t *testing.T) {
+	// 没有主键的表使用 LIMIT OFFSET 翻页
+	type Event struct {
+		Name string
+	}
+	s := NewSession().Model(&Event{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	_, _ = s.Insert(&Event{"a"}, &Event{"b"}, &Event{"c"}, &Event{"d"}, &Event{"e"})
+	var names []string
+	err := s.Model(&Event{}).OrderBy("Name").FindInBatches(2, func(batch interface{}) error {
+		for _, e := range batch.([]Event) {
+			names = append(names, e.Name)
+		}
+		return nil
+	})
+	if err != nil || len(names) != 5 || names[4] != "e" {
+		t.Fatal("failed to find in batches with offset, got", names, err)
+	}
+}
@@ -0,0 +1,219 @@
+package session
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"gamblerORM/generator"
+	"gamblerORM/schema"
+	"go/ast"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// 用于放置将查询结果按列名映射到任意对象的代码，适合联表、聚合等结果不对应某个 Model 的查询，例如
+// var stats []struct{ Name string; Total int }
+// s.Raw("SELECT Name, sum(Amount) AS Total FROM Order GROUP BY Name").Scan(&stats)
+
+var ErrScanColumns = errors.New("scalar destination needs exactly one column")
+
+// Scan 执行 Raw 构造的查询语句，将结果按列名写入 dest
+// 没有调用 Raw 时根据 Model 和链式调用设置的子句构造查询语句，Select 中可以使用聚合等表达式，例如
+// s.Model(&Order{}).Select("UserID", "sum(Amount) AS Total").Group("UserID").Having("sum(Amount) > ?", 100).Scan(&stats)
+// dest 可以是结构体、map[string]interface{}、标量以及它们的切片的指针：
+// 1）结构体根据列名匹配字段，可以匹配字段名、tag 中的 column 或者命名规则转换后的列名，不区分大小写，没有匹配的列会被丢弃
+// 2）map 以列名为 key 保存每一列的值，元素类型不是 interface{} 时转换为元素类型，无法转换时返回错误
+// 3）标量只能接收一列的结果
+// dest 不是切片且没有查询到记录时返回 ErrRecordNotFound
+func (s *Session) Scan(dest interface{}) error {
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Ptr || destValue.IsNil() {
+		return fmt.Errorf("scan.go : dest must be a non-nil pointer, got %T", dest)
+	}
+	destValue = destValue.Elem()
+	if s.sql.Len() == 0 {
+		s.buildScanSQL()
+	}
+	rows, err := s.QueryRows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	isSlice := destValue.Kind() == reflect.Slice && destValue.Type().Elem().Kind() != reflect.Uint8
+	elemType := destValue.Type()
+	if isSlice {
+		elemType = elemType.Elem()
+	}
+	if isScalar(elemType) && len(columns) != 1 {
+		return ErrScanColumns
+	}
+	found := false
+	for rows.Next() {
+		elem := reflect.New(elemType).Elem()
+		if err := s.scanRow(rows, columns, elem); err != nil {
+			return err
+		}
+		found = true
+		if !isSlice {
+			destValue.Set(elem)
+			break
+		}
+		destValue.Set(reflect.Append(destValue, elem))
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if !isSlice && !found {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// buildScanSQL 根据 Model 和链式调用设置的子句构造查询语句
+// Select 中能匹配到字段的按列名加上引号，匹配不到的作为表达式原样保留，没有调用 Select 时查询所有列
+func (s *Session) buildScanSQL() {
+	table := s.RefTable()
+	var columns []string
+	for _, name := range s.selects {
+		if field := table.GetField(name); field != nil {
+			columns = append(columns, s.qualifiedColumn(field.Column))
+		} else if field := table.FieldByColumn(name); field != nil {
+			columns = append(columns, s.qualifiedColumn(field.Column))
+		} else {
+			columns = append(columns, name)
+		}
+	}
+	if len(columns) == 0 {
+		for _, column := range table.FieldNames {
+			columns = append(columns, s.qualifiedColumn(column))
+		}
+	}
+	s.clause.Set(generator.SELECT, s.quote(table.Name), columns)
+	s.softDeleteScope()
+	s.unboundedLimit()
+	sql, vars := s.clause.Build(generator.SELECT, generator.JOIN, generator.WHERE, generator.GROUPBY, generator.HAVING,
+		generator.ORDERBY, generator.LIMIT, generator.OFFSET)
+	s.Raw(sql, vars...)
+}
+
+// scanRow 将当前行写入 elem，elem 必须是可寻址的
+func (s *Session) scanRow(rows *sql.Rows, columns []string, elem reflect.Value) error {
+	values := make([]interface{}, len(columns))
+	switch {
+	case isScalar(elem.Type()):
+		values[0] = elem.Addr().Interface()
+		return rows.Scan(values...)
+	case elem.Kind() == reflect.Map:
+		if elem.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("scan.go : unsupported map type %s", elem.Type())
+		}
+		for i := range values {
+			values[i] = new(interface{})
+		}
+		if err := rows.Scan(values...); err != nil {
+			return err
+		}
+		elem.Set(reflect.MakeMapWithSize(elem.Type(), len(columns)))
+		for i, column := range columns {
+			v, err := convertMapValue(reflect.ValueOf(*(values[i].(*interface{}))), elem.Type().Elem())
+			if err != nil {
+				return fmt.Errorf("scan.go : column %s: %w", column, err)
+			}
+			elem.SetMapIndex(reflect.ValueOf(column), v)
+		}
+		return nil
+	case elem.Kind() == reflect.Struct:
+		indexes := s.columnIndexes(elem.Type())
+		for i, column := range columns {
+			if index, ok := indexes[strings.ToLower(column)]; ok {
+				values[i] = schema.FieldByIndex(elem, index).Addr().Interface()
+			} else {
+				// 结构体中没有对应的字段，丢弃这一列
+				values[i] = new(interface{})
+			}
+		}
+		return rows.Scan(values...)
+	}
+	return fmt.Errorf("scan.go : unsupported scan type %s", elem.Type())
+}
+
+// columnIndexes 返回结构体中可以接收查询结果的字段，key 是小写的列名，value 是字段的索引路径
+// 每个字段可以通过字段名、命名规则转换后的列名以及 tag 中的 column 匹配，tag 为 - 的字段不参与匹配
+// 嵌入的结构体和 schema.Parse 一样会被展开，embeddedPrefix 只作用于列名
+func (s *Session) columnIndexes(typ reflect.Type) map[string][]int {
+	indexes := make(map[string][]int)
+	s.collectColumnIndexes(typ, nil, "", indexes)
+	return indexes
+}
+
+// collectColumnIndexes 将结构体 typ 中字段的索引路径写入 indexes，index 和 prefix 是 typ 所在的路径和列名前缀
+func (s *Session) collectColumnIndexes(typ reflect.Type, index []int, prefix string, indexes map[string][]int) {
+	for i := 0; i < typ.NumField(); i++ {
+		p := typ.Field(i)
+		p.Index = append(append([]int(nil), index...), i)
+		tag := p.Tag.Get("gamblerORM")
+		if tag == "-" {
+			continue
+		}
+		settings := schema.ParseTagSettings(tag)
+		if p.Anonymous || settings.Has("embedded") {
+			embedded := p.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			// 未导出的匿名结构体只能是值类型，否则无法初始化为 nil 的指针
+			if embedded.Kind() == reflect.Struct && !isScalar(embedded) && (p.Type.Kind() != reflect.Ptr || ast.IsExported(p.Name)) {
+				s.collectColumnIndexes(embedded, p.Index, prefix+settings["embeddedprefix"], indexes)
+				continue
+			}
+		}
+		if p.Anonymous || !ast.IsExported(p.Name) {
+			continue
+		}
+		names := []string{prefix + p.Name, prefix + s.naming.ColumnName(p.Name)}
+		if column, ok := settings["column"]; ok {
+			names = append(names, prefix+column)
+		}
+		// 层级浅的字段优先，和 Go 中字段提升的规则以及 schema.Parse 一致
+		for _, name := range names {
+			if old, ok := indexes[strings.ToLower(name)]; !ok || len(old) > len(p.Index) {
+				indexes[strings.ToLower(name)] = p.Index
+			}
+		}
+	}
+}
+
+// convertMapValue 将驱动返回的一列的值转换为 map 的元素类型 typ，NULL 转换为零值
+// 数字不会转换为字符串，避免 65 被转换为 "A"，无法转换时返回错误
+func convertMapValue(v reflect.Value, typ reflect.Type) (reflect.Value, error) {
+	switch {
+	case !v.IsValid():
+		return reflect.Zero(typ), nil
+	case v.Type().AssignableTo(typ):
+		return v, nil
+	case typ.Kind() == reflect.String && v.Kind() != reflect.String && v.Kind() != reflect.Slice:
+	case v.Type().ConvertibleTo(typ):
+		return v.Convert(typ), nil
+	}
+	return reflect.Value{}, fmt.Errorf("cannot convert %s to %s", v.Type(), typ)
+}
+
+// isScalar 判断类型是否作为一个整体接收一列的值，实现了 sql.Scanner 的类型和 time.Time 虽然是结构体，但也是标量
+func isScalar(typ reflect.Type) bool {
+	if reflect.PtrTo(typ).Implements(reflect.TypeOf((*sql.Scanner)(nil)).Elem()) {
+		return true
+	}
+	switch typ.Kind() {
+	case reflect.Struct:
+		return typ == reflect.TypeOf(time.Time{})
+	case reflect.Map:
+		return false
+	}
+	return true
+}
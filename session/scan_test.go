@@ -0,0 +1,73 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+)
+
+type AgeStat struct {
+	Age   int
+	Total int64 `gamblerORM:"column:cnt"`
+	Names string
+}
+
+func TestSession_ScanStruct(t *testing.T) {
+	s := testRecordInit(t)
+	_, _ = s.Insert(user3)
+	var stats []AgeStat
+	err := s.Raw("SELECT Age, count(*) AS cnt, group_concat(Name) AS names, 1 AS unused FROM User GROUP BY Age ORDER BY Age").Scan(&stats)
+	if err != nil || len(stats) != 2 {
+		t.Fatal("failed to scan into struct slice", err)
+	}
+	if stats[0].Age != 18 || stats[0].Total != 1 || stats[1].Age != 25 || stats[1].Total != 2 || stats[1].Names == "" {
+		t.Fatal("failed to map columns by name, got", stats)
+	}
+	var stat AgeStat
+	if err := s.Raw("SELECT count(*) AS cnt FROM User WHERE Age = ?", 25).Scan(&stat); err != nil || stat.Total != 2 {
+		t.Fatal("failed to scan into struct, got", stat)
+	}
+	if err := s.Raw("SELECT Age FROM User WHERE Age > ?", 100).Scan(&stat); err != ErrRecordNotFound {
+		t.Fatal("expect ErrRecordNotFound, but got", err)
+	}
+}
+
+func TestSession_ScanMapAndScalar(t *testing.T) {
+	s := testRecordInit(t)
+	var rows []map[string]interface{}
+	if err := s.Raw("SELECT Name, Age FROM User ORDER BY Age").Scan(&rows); err != nil || len(rows) != 2 {
+		t.Fatal("failed to scan into map slice", err)
+	}
+	if rows[0]["Name"] != "Tom" || rows[0]["Age"] != int64(18) {
+		t.Fatal("failed to scan map values, got", rows[0])
+	}
+	var count int
+	if err := s.Raw("SELECT count(*) FROM User").Scan(&count); err != nil || count != 2 {
+		t.Fatal("failed to scan into scalar, got", count)
+	}
+	var names []string
+	if err := s.Raw("SELECT Name FROM User ORDER BY Age").Scan(&names); err != nil || !reflect.DeepEqual(names, []string{"Tom", "Sam"}) {
+		t.Fatal("failed to scan into scalar slice, got", names)
+	}
+	if err := s.Raw("SELECT Name, Age FROM User").Scan(&names); err != ErrScanColumns {
+		t.Fatal("expect ErrScanColumns, but got", err)
+	}
+}
+
+func TestSession_ScanTypedMap(t *testing.T) {
+	s := testRecordInit(t)
+	var ages []map[string]int
+	if err := s.Raw("SELECT Age, Age * 2 AS Double FROM User ORDER BY Age").Scan(&ages); err != nil || len(ages) != 2 {
+		t.Fatal("failed to scan into map[string]int", err)
+	}
+	if ages[0]["Age"] != 18 || ages[1]["Double"] != 50 {
+		t.Fatal("failed to convert map values, got", ages)
+	}
+	var names map[string]string
+	if err := s.Raw("SELECT Name FROM User WHERE Age = ?", 18).Scan(&names); err != nil || names["Name"] != "Tom" {
+		t.Fatal("failed to scan into map[string]string, got", names, err)
+	}
+	// 数字不会被转换为字符串
+	if err := s.Raw("SELECT Name, Age FROM User").Scan(&names); err == nil {
+		t.Fatal("expect error when converting number to string")
+	}
+}
@@ -1,24 +1,35 @@
 package session
 
 import (
+	"context"
 	"database/sql"
 	"gamblerORM/dialect"
 	"gamblerORM/generator"
 	"gamblerORM/log"
 	"gamblerORM/schema"
 	"strings"
+	"time"
 )
 
 // Session 用于实现与数据库的交互
 
 type Session struct {
-	db       *sql.DB          // 使用 sql.Open() 方法连接数据库成功之后返回的指针
-	sql      strings.Builder  // 拼接 SQL 语句,调用 Raw() 方法即可改变以下两个变量的值
-	sqlVars  []interface{}    // SQL 语句中占位符的对应值
-	dialect  dialect.Dialect  // 存储对不同数据库的匹配
-	refTable *schema.Schema   // 代表一张表的信息
-	clause   generator.Clause // 添加 clause 用于拼接字符串
-	tx       *sql.Tx          // 添加对事务的支持，使用 tx 来实现事务
+	db       *sql.DB               // 使用 sql.Open() 方法连接数据库成功之后返回的指针
+	sql      strings.Builder       // 拼接 SQL 语句,调用 Raw() 方法即可改变以下两个变量的值
+	sqlVars  []interface{}         // SQL 语句中占位符的对应值
+	dialect  dialect.Dialect       // 存储对不同数据库的匹配
+	refTable *schema.Schema        // 代表一张表的信息
+	refErr   error                 // 解析 refTable 时的错误，执行语句时返回
+	err      error                 // 构造条件时的错误，执行下一条语句时返回
+	clause   generator.Clause      // 添加 clause 用于拼接字符串
+	tx       *sql.Tx               // 添加对事务的支持，使用 tx 来实现事务
+	naming   schema.NamingStrategy // 表名和列名的命名规则
+	ctx      context.Context       // 执行 SQL 时使用的上下文，用于取消查询和传递超时
+	selects  []string              // Select 指定的字段，只对下一条语句生效
+	omits    []string              // Omit 排除的字段，只对下一条语句生效
+	preloads []string              // Preload 指定的关联字段，只对下一次 Find 生效
+	unscoped bool                  // 是否忽略软删除，只对下一条语句生效
+	nowFunc  func() time.Time      // 自动时间戳和软删除使用的时钟，为 nil 时使用 time.Now
 }
 
 // CommonDB 定义一个集合，用于实现 事务方式使用数据库
@@ -26,6 +37,9 @@ type CommonDB interface {
 	Query(query string, args ...interface{}) (*sql.Rows, error)
 	QueryRow(query string, args ...interface{}) *sql.Row
 	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 }
 
 // New 创建一个新的 Session 用来操作数据库，Session中有db操作句柄和对不同数据库的适配
@@ -33,6 +47,7 @@ func New(db *sql.DB, dialect dialect.Dialect) *Session {
 	return &Session{
 		db:      db,
 		dialect: dialect,
+		naming:  schema.DefaultNaming{},
 	}
 }
 
@@ -41,6 +56,19 @@ func (s *Session) Clear() {
 	s.sql.Reset()
 	s.sqlVars = nil
 	s.clause = generator.Clause{}
+	s.selects = nil
+	s.omits = nil
+	s.preloads = nil
+	s.unscoped = false
+	s.err = nil
+}
+
+// stmtErr 返回执行语句前已经记录的错误，解析模型的错误优先
+func (s *Session) stmtErr() error {
+	if s.refErr != nil {
+		return s.refErr
+	}
+	return s.err
 }
 
 // 用于检查这两种使用数据库的方式中，是否全部实现了接口要求的方法
@@ -55,6 +83,33 @@ func (s *Session) DB() CommonDB {
 	return s.db
 }
 
+// WithContext 设置会话执行 SQL 时使用的上下文，上下文被取消或超时后正在执行的语句会被中断
+func (s *Session) WithContext(ctx context.Context) *Session {
+	s.ctx = ctx
+	return s
+}
+
+// Context 返回会话的上下文，没有设置时返回 context.Background()
+func (s *Session) Context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
+// SetNowFunc 设置自动时间戳和软删除使用的时钟，测试中可以传入固定的时间
+func (s *Session) SetNowFunc(now func() time.Time) {
+	s.nowFunc = now
+}
+
+// now 返回时钟的当前时间
+func (s *Session) now() time.Time {
+	if s.nowFunc == nil {
+		return time.Now()
+	}
+	return s.nowFunc()
+}
+
 // Raw 用来改变 Session 中的 sql 和 sqlVars 字段，这两个字符用来拼接 sql 语句
 func (s *Session) Raw(sql string, values ...interface{}) *Session {
 	s.sql.WriteString(sql)
@@ -63,36 +118,67 @@ func (s *Session) Raw(sql string, values ...interface{}) *Session {
 	return s
 }
 
+// quote 使用 dialect 给标识符加上引号
+func (s *Session) quote(name string) string {
+	return s.dialect.QuoteIdentifier(name)
+}
+
+// quoteAll 给一组标识符加上引号
+func (s *Session) quoteAll(names []string) []string {
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, s.quote(name))
+	}
+	return quoted
+}
+
+// query 返回最终交给驱动执行的 SQL 语句，会根据 dialect 把 ? 占位符改写为数据库要求的风格
+func (s *Session) query() string {
+	if s.dialect == nil {
+		return s.sql.String()
+	}
+	return dialect.Rebind(s.dialect.BindVar(), s.sql.String())
+}
+
 // Exec 封装 sql 的Exec()方法，可以统一打印日志和清除sql语句
 func (s *Session) Exec() (result sql.Result, err error) {
 	// 使用完毕后关闭数据库连接
 	defer s.Clear()
-	log.Info(s.sql.String(), s.sqlVars)
-	if result, err = s.DB().Exec(s.sql.String(), s.sqlVars...); err != nil {
+	if err = s.stmtErr(); err != nil {
+		return nil, err
+	}
+	sql := s.query()
+	log.Info(sql, s.sqlVars)
+	if result, err = s.DB().ExecContext(s.Context(), sql, s.sqlVars...); err != nil {
 		// log.go 中定义 Error = errorLog.Println
 		log.Error(err)
 	}
 	return
 }
 
-//QueryRow 封装 sql 的 QueryRow 方法，从数据库中获取一条数据
+// QueryRow 封装 sql 的 QueryRow 方法，从数据库中获取一条数据
 func (s *Session) QueryRow() *sql.Row {
 	//执行查询之前先清空 sql
 	defer s.Clear()
 	// log.go 中定义 Info = infoLog.Println
-	log.Info(s.sql.String(), s.sqlVars)
+	sql := s.query()
+	log.Info(sql, s.sqlVars)
 	// 实际执行
-	return s.DB().QueryRow(s.sql.String(), s.sqlVars...)
+	return s.DB().QueryRowContext(s.Context(), sql, s.sqlVars...)
 }
 
 // QueryRows 封装 sql 的 Query 方法，从数据库中获取多条数据
 func (s *Session) QueryRows() (rows *sql.Rows, err error) {
 	//执行查询之前先清空 sql
 	defer s.Clear()
+	if err = s.stmtErr(); err != nil {
+		return nil, err
+	}
 	// log.go 中定义 Info = infoLog.Println
-	log.Info(s.sql.String(), s.sqlVars)
+	sql := s.query()
+	log.Info(sql, s.sqlVars)
 	// 实际执行
-	if rows, err = s.DB().Query(s.sql.String(), s.sqlVars...); err != nil {
+	if rows, err = s.DB().QueryContext(s.Context(), sql, s.sqlVars...); err != nil {
 		log.Error(err)
 	}
 	return
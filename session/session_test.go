@@ -1,6 +1,7 @@
 package session
 
 import (
+	"context"
 	"database/sql"
 	"gamblerORM/dialect"
 	_ "github.com/mattn/go-sqlite3"
@@ -44,3 +45,48 @@ func TestSession_QueryRows(t *testing.T) {
 		t.Fatal("failed to query db", err)
 	}
 }
+
+func TestSession_Rebind(t *testing.T) {
+	postgresDialect, _ := dialect.GetDialect("postgres")
+	s := New(nil, postgresDialect).Raw("SELECT * FROM User WHERE Name = ?", "Tom")
+	s.Raw("LIMIT ?", 3)
+	if sql := s.query(); sql != "SELECT * FROM User WHERE Name = $1 LIMIT $2 " {
+		t.Fatal("failed to rebind placeholders, got", sql)
+	}
+}
+
+func TestSession_WithContext(t *testing.T) {
+	s := NewSession().Model(&User{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	_, _ = s.Insert(&User{"Tom", 18})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var users []User
+	if err := s.WithContext(ctx).Find(&users); err != context.Canceled || len(users) != 0 {
+		t.Fatal("expect cancelled context to abort Find, but got", err)
+	}
+	// 换回正常的上下文后可以继续使用会话
+	if err := s.WithContext(context.Background()).Find(&users); err != nil || len(users) != 1 {
+		t.Fatal("failed to query with context", err)
+	}
+}
+
+func TestSession_BeginTx(t *testing.T) {
+	s := NewSession().Model(&User{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	opts := &sql.TxOptions{Isolation: sql.LevelSerializable}
+	if err := s.BeginTx(opts); err != nil {
+		t.Fatal("failed to begin transaction with options", err)
+	}
+	_, _ = s.Insert(&User{"Tom", 18})
+	if err := s.Commit(); err != nil {
+		t.Fatal("failed to commit", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewSession().WithContext(ctx).BeginTx(nil); err == nil {
+		t.Fatal("expect cancelled context to abort BeginTx")
+	}
+}
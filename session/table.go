@@ -15,11 +15,34 @@ func (s *Session) Model(value interface{}) *Session {
 	// 解析操作比较耗时，所以要把解析的结果保存到 refTable 中，如果传入 Moder 的结构体名称不变，那么就不会更新 refTable 的值
 	if s.refTable == nil || reflect.TypeOf(value) != reflect.TypeOf(s.refTable.Model) {
 		// 保存解析结果，这个结果是一张表的信息，是 schema 结构的
-		s.refTable = schema.Parse(value, s.dialect)
+		// 解析出错时保存错误，由之后执行的语句返回，避免按不完整的表结构操作数据库
+		s.refTable, s.refErr = schema.ParseWithNaming(value, s.dialect, s.naming)
+		if s.refErr != nil {
+			log.Error(s.refErr)
+		}
+	} else {
+		// 类型相同时不需要重新解析，但是要记住最新传入的对象，Updates 等方法会从中读取主键
+		s.refTable.Model = value
 	}
 	return s
 }
 
+// SetNamingStrategy 设置表名和列名的命名规则，会丢弃已有的解析结果
+func (s *Session) SetNamingStrategy(naming schema.NamingStrategy) {
+	s.naming = naming
+	s.refTable, s.refErr = nil, nil
+}
+
+// columnOf 将用户传入的字段名转换为列名，找不到对应字段时认为传入的已经是列名
+func (s *Session) columnOf(name string) string {
+	if s.refTable != nil {
+		if field := s.refTable.GetField(name); field != nil {
+			return field.Column
+		}
+	}
+	return name
+}
+
 // RefTable 返回 refTable 的值
 func (s *Session) RefTable() *schema.Schema {
 	// 如果没有被赋值则打印错误日志
@@ -29,30 +52,106 @@ func (s *Session) RefTable() *schema.Schema {
 	return s.refTable
 }
 
-// CreateTable 创建表
+// CreateTable 创建表，如果有字段设置了索引，建表之后一并创建索引，有 many2many 关联时一并创建中间表
 func (s *Session) CreateTable() error {
+	// 创建表的实际操作
+	if _, err := s.Raw(s.createTableSQL()).Exec(); err != nil {
+		return err
+	}
+	for _, sql := range s.createIndexSQL() {
+		if _, err := s.Raw(sql).Exec(); err != nil {
+			return err
+		}
+	}
+	return s.CreateJoinTables()
+}
+
+// CreateJoinTables 创建 many2many 关联的中间表，关联的两端共用一张中间表，已经存在时跳过
+func (s *Session) CreateJoinTables() error {
+	for _, rel := range s.RefTable().Relationships {
+		if rel.JoinTable == nil {
+			continue
+		}
+		j := s.joinSession(rel)
+		if j.JudgeTableExist() {
+			continue
+		}
+		if _, err := j.Raw(j.createTableSQL()).Exec(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createTableSQL 根据解析结果拼接建表语句，单独拆出来便于在没有数据库连接时验证不同 dialect 生成的 DDL
+func (s *Session) createTableSQL() string {
 	// table 是解析结果，是 schema 结构体的形式
 	table := s.RefTable()
+	var primaryKeys []string
+	for _, field := range table.PrimaryFields {
+		primaryKeys = append(primaryKeys, s.quote(field.Column))
+	}
 	// 列信息
 	var columns []string
-	// 拿到 Fields 里面的 Field 并追加到 列信息里面
+	// 拿到 Fields 里面的 Field，由 dialect 渲染为对应数据库的列定义
 	for _, field := range table.Fields {
-		columns = append(columns, fmt.Sprintf("%s %s %s", field.Name, field.Type, field.Tag))
+		col := field.ColumnDef()
+		// 联合主键不能写在列定义中，需要作为表的约束单独声明
+		if len(primaryKeys) > 1 {
+			col.PrimaryKey = false
+		}
+		columns = append(columns, s.dialect.ColumnSQL(col))
+	}
+	if len(primaryKeys) > 1 {
+		columns = append(columns, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(primaryKeys, ", ")))
 	}
-	// 用 , 来连接 每一对 field.Name field.Type field.Tag 的值
+	// 用 , 来连接每一列的定义
 	desc := strings.Join(columns, ",")
-	// 创建表的实际操作
-	_, err := s.Raw(fmt.Sprintf("CREATE TABLE %s (%s);", table.Name, desc)).Exec()
-	return err
+	return fmt.Sprintf("CREATE TABLE %s (%s);", s.quote(table.Name), desc)
 }
 
-// DropTable 删除表
+// createIndexSQL 返回创建索引的语句，索引名相同的列组成联合索引，列的顺序和字段的顺序一致
+func (s *Session) createIndexSQL() []string {
+	table := s.RefTable()
+	var names []string
+	indexes := make(map[string][]string)
+	for _, field := range table.Fields {
+		if field.Index == "" {
+			continue
+		}
+		if _, ok := indexes[field.Index]; !ok {
+			names = append(names, field.Index)
+		}
+		indexes[field.Index] = append(indexes[field.Index], s.quote(field.Column))
+	}
+	var sqls []string
+	for _, name := range names {
+		sqls = append(sqls, fmt.Sprintf("CREATE INDEX %s ON %s (%s);",
+			s.quote(name), s.quote(table.Name), strings.Join(indexes[name], ", ")))
+	}
+	return sqls
+}
+
+// DropTable 删除表，many2many 关联的中间表由关联的两端共用，不会一并删除，需要时调用 DropJoinTables
 func (s *Session) DropTable() error {
 	// s.RefTable() 是 解析后的 schema 结构的结果，其中 Name 字段是表名
-	_, err := s.Raw(fmt.Sprintf("DROP TABLE IF EXISTS %s", s.RefTable().Name)).Exec()
+	_, err := s.Raw(fmt.Sprintf("DROP TABLE IF EXISTS %s", s.quote(s.RefTable().Name))).Exec()
 	return err
 }
 
+// DropJoinTables 删除 many2many 关联的中间表，关联另一端的对象也会失去这些关联
+func (s *Session) DropJoinTables() error {
+	for _, rel := range s.RefTable().Relationships {
+		if rel.JoinTable == nil {
+			continue
+		}
+		if _, err := s.Raw(fmt.Sprintf("DROP TABLE IF EXISTS %s", s.quote(rel.JoinTable.Name))).Exec(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // JudgeTableExist 判断表是否存在
 func (s *Session) JudgeTableExist() bool {
 	// 拿到判断表名是s.RefTable().Name的表是否存在的对应数据库的 sql 语句
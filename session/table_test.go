@@ -1,6 +1,8 @@
 package session
 
 import (
+	"gamblerORM/dialect"
+	"gamblerORM/schema"
 	"testing"
 )
 
@@ -27,3 +29,100 @@ func TestSession_Model(t *testing.T) {
 		t.Fatal("Failed to change model")
 	}
 }
+
+func TestSession_CreateTableSQL(t *testing.T) {
+	// 不需要真实的 mysql 服务，只验证生成的 DDL
+	mysqlDialect, _ := dialect.GetDialect("mysql")
+	s := New(nil, mysqlDialect).Model(&User{})
+	sql := s.createTableSQL()
+	if sql != "CREATE TABLE `User` (`Name` varchar(255) PRIMARY KEY,`Age` bigint);" {
+		t.Fatal("failed to build mysql DDL, got", sql)
+	}
+}
+
+func TestSession_CreateTableSQLQuote(t *testing.T) {
+	// 表名和列名是 postgres 的关键字时也能正确建表
+	type Order struct {
+		Group string
+		Limit int
+	}
+	postgresDialect, _ := dialect.GetDialect("postgres")
+	s := New(nil, postgresDialect).Model(&Order{})
+	sql := s.createTableSQL()
+	if sql != `CREATE TABLE "Order" ("Group" text,"Limit" bigint);` {
+		t.Fatal("failed to quote identifiers, got", sql)
+	}
+}
+
+func TestSession_SetNamingStrategy(t *testing.T) {
+	type UserProfile struct {
+		UserName string `gamblerORM:"PRIMARY KEY"`
+		UserAge  int    `gamblerORM:"column:age"`
+	}
+	s := NewSession()
+	s.SetNamingStrategy(schema.SnakeCaseNaming{})
+	s.Model(&UserProfile{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	if !s.JudgeTableExist() {
+		t.Fatal("Failed to create table user_profile")
+	}
+	_, _ = s.Insert(&UserProfile{"Tom", 18})
+	_, _ = s.Where("user_name = ?", "Tom").Update("UserAge", 20)
+	var profiles []UserProfile
+	if err := s.Find(&profiles); err != nil || len(profiles) != 1 || profiles[0].UserAge != 20 {
+		t.Fatal("failed to query with naming strategy, got", profiles)
+	}
+}
+
+type Member struct {
+	GroupID int64  `gamblerORM:"primary_key"`
+	UserID  int64  `gamblerORM:"primary_key"`
+	Role    string `gamblerORM:"size:32;not_null;default:'guest';index"`
+}
+
+func TestSession_CreateTableSQLConstraints(t *testing.T) {
+	cases := []struct {
+		Dialect string
+		SQL     string
+	}{
+		{"sqlite3", "CREATE TABLE `Member` (`GroupID` bigint,`UserID` bigint,`Role` text NOT NULL DEFAULT 'guest',PRIMARY KEY (`GroupID`, `UserID`));"},
+		{"mysql", "CREATE TABLE `Member` (`GroupID` bigint,`UserID` bigint,`Role` varchar(32) NOT NULL DEFAULT 'guest',PRIMARY KEY (`GroupID`, `UserID`));"},
+		{"postgres", `CREATE TABLE "Member" ("GroupID" bigint,"UserID" bigint,"Role" varchar(32) NOT NULL DEFAULT 'guest',PRIMARY KEY ("GroupID", "UserID"));`},
+	}
+	for _, c := range cases {
+		d, _ := dialect.GetDialect(c.Dialect)
+		s := New(nil, d).Model(&Member{})
+		if sql := s.createTableSQL(); sql != c.SQL {
+			t.Fatalf("%s: expect %s, but got %s", c.Dialect, c.SQL, sql)
+		}
+	}
+}
+
+func TestSession_CreateTableWithIndex(t *testing.T) {
+	s := NewSession().Model(&Member{})
+	_ = s.DropTable()
+	if err := s.CreateTable(); err != nil {
+		t.Fatal("failed to create table with index", err)
+	}
+	var name string
+	row := s.Raw("SELECT name FROM sqlite_master WHERE type='index' AND tbl_name = ? AND sql IS NOT NULL", "Member").QueryRow()
+	if err := row.Scan(&name); err != nil || name != "idx_Member_Role" {
+		t.Fatal("failed to create index, got", name)
+	}
+}
+
+type Coupon struct {
+	Code   string `gamblerORM:"primary_key"`
+	Amount int    `gamblerORM:"check:Amount > 0"`
+}
+
+func TestSession_CreateTableInvalidTag(t *testing.T) {
+	s := NewSession().Model(&Coupon{})
+	if err := s.CreateTable(); err == nil || s.JudgeTableExist() {
+		t.Fatal("expect error for unknown tag setting, got", err)
+	}
+	if _, err := s.Insert(&Coupon{Code: "a", Amount: 1}); err == nil {
+		t.Fatal("expect error when inserting with invalid model")
+	}
+}
@@ -1,6 +1,7 @@
 package session
 
 import (
+	"database/sql"
 	"gamblerORM/log"
 )
 
@@ -8,9 +9,14 @@ import (
 
 // Begin 封装事务的Begin方法
 func (s *Session) Begin() (err error) {
+	return s.BeginTx(nil)
+}
+
+// BeginTx 使用会话的上下文开启事务，opts 可以指定隔离级别和是否只读，为 nil 时使用数据库的默认设置
+func (s *Session) BeginTx(opts *sql.TxOptions) (err error) {
 	log.Info("Transaction Begin")
-	// 调用 s.db.Begin() 得到 *sql.Tx 对象，赋值给 s.tx
-	if s.tx, err = s.db.Begin(); err != nil {
+	// 调用 s.db.BeginTx() 得到 *sql.Tx 对象，赋值给 s.tx，上下文被取消时事务会自动回滚
+	if s.tx, err = s.db.BeginTx(s.Context(), opts); err != nil {
 		log.Error(err)
 		return
 	}